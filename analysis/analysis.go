@@ -0,0 +1,197 @@
+/* Package analysis checks Scratch 3 projects for common problems, and measures
+ * how large and complex they are.
+ */
+package analysis
+
+import "strings"
+import "github.com/scapi3/sb3"
+
+/* Report holds the results of analyzing a project.
+ */
+type Report struct {
+	// Blocks is the total amount of blocks in the project, not counting
+	// shadow blocks.
+	Blocks int
+
+	// Categories and Opcodes count the blocks in the project by category
+	// and by opcode. The category of a block is the part of its opcode
+	// before the first underscore, such as "motion" or "pen".
+	Categories map[string] int
+	Opcodes    map[string] int
+
+	// Scripts holds measurements for each script in the project.
+	Scripts []ScriptReport
+
+	// Complexity is the sum of the complexity of every script.
+	Complexity int
+
+	// Findings lists the problems that were found in the project.
+	Findings []Finding
+}
+
+/* ScriptReport holds measurements for a single script.
+ */
+type ScriptReport struct {
+	Target  string
+	BlockID string
+	Opcode  string
+	Blocks  int
+
+	// Complexity is one more than the amount of places where the script
+	// can take different paths, such as loops, conditions, and boolean
+	// operators.
+	Complexity int
+
+	// Depth is the deepest level of nesting inside of C blocks.
+	Depth int
+}
+
+/* Analyze analyzes a project.
+ */
+func Analyze (project *sb3.Project) (report Report) {
+	report.Categories = make(map[string] int)
+	report.Opcodes    = make(map[string] int)
+
+	for _, target := range project.Targets {
+		for _, id := range target.Scripts() {
+			script := analyzeScript(target, id, &report)
+			report.Scripts = append(report.Scripts, script)
+			report.Complexity += script.Complexity
+		}
+	}
+
+	report.Findings = append(report.Findings, findUnused(project)...)
+	for _, target := range project.Targets {
+		report.Findings = append (
+			report.Findings,
+			findUnreachable(target)...)
+		report.Findings = append (
+			report.Findings,
+			findMissingCostumes(project, target)...)
+		report.Findings = append (
+			report.Findings,
+			findUndefinedProcedures(target)...)
+	}
+	return
+}
+
+/* analyzeScript measures a script, and adds its blocks to the report's counts.
+ */
+func analyzeScript (
+	target *sb3.Target,
+	id     string,
+	report *Report,
+) (
+	script ScriptReport,
+) {
+	script = ScriptReport {
+		Target:     target.Name,
+		BlockID:    id,
+		Opcode:     opcode(target.Blocks[id]),
+		Complexity: 1,
+	}
+
+	walkScript(target, id, func (block *sb3.Block, depth int) {
+		if block.Shadow { return }
+		blockOpcode := opcode(block)
+		
+		script.Blocks ++
+		report.Blocks ++
+		report.Opcodes[blockOpcode] ++
+		report.Categories[Category(blockOpcode)] ++
+		
+		if depth > script.Depth { script.Depth = depth }
+		script.Complexity += branches[blockOpcode]
+	})
+	return
+}
+
+// branches holds the amount of decision points that each opcode adds to the
+// complexity of a script.
+var branches = map[string] int {
+	"control_if":           1,
+	"control_if_else":      1,
+	"control_repeat":       1,
+	"control_repeat_until": 1,
+	"control_while":        1,
+	"control_for_each":     1,
+	"control_forever":      1,
+	"control_wait_until":   1,
+	"operator_and":         1,
+	"operator_or":          1,
+}
+
+/* Category returns the category of an opcode, which is the part before the
+ * first underscore.
+ */
+func Category (opcode string) (category string) {
+	category, _, _ = strings.Cut(opcode, "_")
+	return
+}
+
+/* opcode returns the opcode of a block. Blocks stored as primitives are given
+ * the opcode of the reporter they represent.
+ */
+func opcode (block *sb3.Block) (opcode string) {
+	if block == nil { return "" }
+	if block.Primitive == nil { return block.Opcode }
+	
+	switch block.Primitive.Type {
+	case sb3.PrimitiveTypeVariable:  return "data_variable"
+	case sb3.PrimitiveTypeList:      return "data_listcontents"
+	case sb3.PrimitiveTypeBroadcast: return "event_broadcast_menu"
+	default:                         return "primitive"
+	}
+}
+
+/* walkScript calls callback for every block in a script, including blocks in
+ * inputs and C blocks. Depth is the amount of C blocks that a block is inside
+ * of. Variable and list reporters that are stored inline as primitives are
+ * given to callback as blocks holding only the primitive.
+ */
+func walkScript (
+	target   *sb3.Target,
+	id       string,
+	callback func (block *sb3.Block, depth int),
+) {
+	visited := map[string] bool { }
+	var walk func (id string, depth int)
+	walk = func (id string, depth int) {
+		for _, id := range target.Stack(id) {
+			if visited[id] { return }
+			visited[id] = true
+			
+			block := target.Blocks[id]
+			callback(block, depth)
+
+			for _, name := range sb3.SortedKeys(block.Inputs) {
+				input := block.Inputs[name]
+				inner := depth
+				if strings.HasPrefix(name, "SUBSTACK") {
+					inner ++
+				}
+				if input.Block.BlockID != "" {
+					walk(input.Block.BlockID, inner)
+				}
+				if isReporter(input.Block.Primitive) {
+					callback (
+						&sb3.Block { Primitive: input.Block.Primitive },
+						inner)
+				}
+				if input.Shadow.BlockID != "" {
+					walk(input.Shadow.BlockID, inner)
+				}
+			}
+		}
+	}
+	walk(id, 0)
+}
+
+/* isReporter returns whether a primitive stands for a variable or list
+ * reporter.
+ */
+func isReporter (primitive *sb3.Primitive) (reporter bool) {
+	return primitive != nil &&
+		(primitive.Type == sb3.PrimitiveTypeVariable ||
+		primitive.Type == sb3.PrimitiveTypeList)
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import "testing"
+import "github.com/scapi3/sb3"
+
+// costume is a costume for the targets in test projects.
+const costume = `{"assetId":"a","name":"costume1","dataFormat":"svg","md5ext":"a.svg"}`
+
+/* parseProject parses a project with a stage and a sprite named Cat. The stage
+ * is given the specified extra keys, and the sprite the specified blocks.
+ */
+func parseProject (test *testing.T, stage, blocks string) (project *sb3.Project) {
+	test.Helper()
+	if stage != "" { stage = "," + stage }
+	project, err := sb3.Parse([]byte (
+		`{"targets":[` +
+		`{"isStage":true,"name":"Stage","costumes":[` + costume + `]` + stage + `},` +
+		`{"isStage":false,"name":"Cat","costumes":[` + costume + `],"blocks":` + blocks + `}]}`))
+	if err != nil { test.Fatal(err) }
+	return
+}
+
+func TestAnalyzeScripts (test *testing.T) {
+	project := parseProject (
+		test,
+		`"variables":{"v":["score",0]},"lists":{"l":["items",[]]}`,
+		`{
+			"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+			"b": {"opcode": "control_repeat", "next": null, "parent": "a", "inputs": {"TIMES": [1, [6, "10"]], "SUBSTACK": [2, "c"]}, "fields": {}, "shadow": false, "topLevel": false},
+			"c": {"opcode": "motion_movesteps", "next": "e", "parent": "b", "inputs": {"STEPS": [3, [12, "score", "v"], [4, "10"]]}, "fields": {}, "shadow": false, "topLevel": false},
+			"e": {"opcode": "control_if", "next": null, "parent": "c", "inputs": {"CONDITION": [2, "f"], "SUBSTACK": [2, "g"]}, "fields": {}, "shadow": false, "topLevel": false},
+			"f": {"opcode": "operator_and", "next": null, "parent": "e", "inputs": {}, "fields": {}, "shadow": false, "topLevel": false},
+			"g": {"opcode": "data_addtolist", "next": null, "parent": "e", "inputs": {"ITEM": [3, [13, "items", "l"], [10, ""]]}, "fields": {"LIST": ["items", "l"]}, "shadow": false, "topLevel": false},
+			"h": [12, "score", "v", 300, 0]
+		}`)
+	report := Analyze(project)
+
+	expected := []ScriptReport {
+		{ Target: "Cat", BlockID: "a", Opcode: "event_whenflagclicked", Blocks: 8, Complexity: 4, Depth: 2 },
+		{ Target: "Cat", BlockID: "h", Opcode: "data_variable", Blocks: 1, Complexity: 1, Depth: 0 },
+	}
+	if len(report.Scripts) != len(expected) {
+		test.Fatalf("scripts are %+v, expected %+v", report.Scripts, expected)
+	}
+	for index, script := range report.Scripts {
+		if script != expected[index] {
+			test.Errorf("script %d is %+v, expected %+v", index, script, expected[index])
+		}
+	}
+	if report.Blocks != 9 || report.Complexity != 5 {
+		test.Errorf("report has %d blocks and complexity %d, expected 9 and 5", report.Blocks, report.Complexity)
+	}
+
+	// variables and lists that are stored inline are counted as reporters
+	for _, testCase := range []struct {
+		counts   map[string] int
+		key      string
+		expected int
+	} {
+		{ report.Opcodes,    "data_variable",     2 },
+		{ report.Opcodes,    "data_listcontents", 1 },
+		{ report.Opcodes,    "control_repeat",    1 },
+		{ report.Opcodes,    "primitive",         0 },
+		{ report.Categories, "data",              4 },
+		{ report.Categories, "control",           2 },
+		{ report.Categories, "event",             1 },
+		{ report.Categories, "motion",            1 },
+		{ report.Categories, "operator",          1 },
+	} {
+		if testCase.counts[testCase.key] != testCase.expected {
+			test.Errorf (
+				"count of %s is %d, expected %d",
+				testCase.key, testCase.counts[testCase.key], testCase.expected)
+		}
+	}
+}
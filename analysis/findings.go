@@ -0,0 +1,344 @@
+package analysis
+
+import "fmt"
+import "strings"
+import "github.com/scapi3/sb3"
+
+/* FindingKind represents the kind of problem that a finding describes.
+ */
+type FindingKind string
+
+const (
+	// FindingUnusedVariable is reported for variables that no block uses.
+	FindingUnusedVariable FindingKind = "unused-variable"
+
+	// FindingUnusedList is reported for lists that no block uses.
+	FindingUnusedList FindingKind = "unused-list"
+
+	// FindingUnusedBroadcast is reported for broadcasts that are never
+	// sent, or never received.
+	FindingUnusedBroadcast FindingKind = "unused-broadcast"
+
+	// FindingUnreachableScript is reported for scripts that can never run,
+	// because they do not start with a hat block, or because they define a
+	// custom block that is never used.
+	FindingUnreachableScript FindingKind = "unreachable-script"
+
+	// FindingMissingCostume is reported for blocks that switch to a costume
+	// or backdrop that does not exist, and for targets with no costumes.
+	FindingMissingCostume FindingKind = "missing-costume"
+
+	// FindingUndefinedProcedure is reported for custom block calls that
+	// have no matching definition.
+	FindingUndefinedProcedure FindingKind = "undefined-procedure"
+)
+
+/* Finding describes a single problem in a project. Target is the name of the
+ * target where the problem is, and ID is the ID of the variable, list,
+ * broadcast, or block concerned.
+ */
+type Finding struct {
+	Kind    FindingKind
+	Target  string
+	ID      string
+	Message string
+}
+
+/* String returns a description of the finding.
+ */
+func (finding Finding) String () (description string) {
+	return fmt.Sprintf (
+		"%s: %s: %s",
+		finding.Target, finding.Kind, finding.Message)
+}
+
+/* findUnused finds variables, lists, and broadcasts that are not used. Blocks
+ * usually refer to these by ID, but some refer to them only by name, so both
+ * are checked.
+ */
+func findUnused (project *sb3.Project) (findings []Finding) {
+	usedIDs   := map[string] bool { }
+	usedNames := map[string] bool { }
+	sent      := map[string] bool { }
+	received  := map[string] bool { }
+	use := func (kind, id, name string) {
+		usedIDs[kind + id]     = true
+		usedNames[kind + name] = true
+	}
+
+	for _, target := range project.Targets {
+		for _, block := range target.Blocks {
+			if block.Primitive != nil {
+				usePrimitive(block.Primitive, use, sent)
+				continue
+			}
+			
+			for _, input := range block.Inputs {
+				for _, value := range []sb3.InputValue {
+					input.Block, input.Shadow,
+				} {
+					if value.Primitive == nil { continue }
+					usePrimitive(value.Primitive, use, sent)
+				}
+			}
+			
+			for name, field := range block.Fields {
+				switch name {
+				case "VARIABLE":
+					use("variable", field.ID, field.String())
+				case "LIST":
+					use("list", field.ID, field.String())
+				case "BROADCAST_OPTION":
+					use("broadcast", field.ID, field.String())
+					key := broadcastKey(field.String())
+					if block.Opcode == "event_whenbroadcastreceived" {
+						received[key] = true
+					} else {
+						sent[key] = true
+					}
+				}
+			}
+		}
+	}
+
+	used := func (kind, id, name string) bool {
+		return usedIDs[kind + id] || usedNames[kind + name]
+	}
+
+	for _, target := range project.Targets {
+		for _, id := range sb3.SortedKeys(target.Variables) {
+			variable := target.Variables[id]
+			if used("variable", id, variable.Name) { continue }
+			findings = append(findings, Finding {
+				Kind:    FindingUnusedVariable,
+				Target:  target.Name,
+				ID:      id,
+				Message: fmt.Sprintf (
+					"variable %q is never used",
+					variable.Name),
+			})
+		}
+		
+		for _, id := range sb3.SortedKeys(target.Lists) {
+			list := target.Lists[id]
+			if used("list", id, list.Name) { continue }
+			findings = append(findings, Finding {
+				Kind:    FindingUnusedList,
+				Target:  target.Name,
+				ID:      id,
+				Message: fmt.Sprintf("list %q is never used", list.Name),
+			})
+		}
+		
+		for _, id := range sb3.SortedKeys(target.Broadcasts) {
+			name := target.Broadcasts[id]
+			key  := broadcastKey(name)
+			
+			message := ""
+			if !used("broadcast", id, name) {
+				message = "is never used"
+			} else if !received[key] {
+				message = "is never received"
+			} else if !sent[key] {
+				message = "is never sent"
+			}
+			if message == "" { continue }
+			
+			findings = append(findings, Finding {
+				Kind:    FindingUnusedBroadcast,
+				Target:  target.Name,
+				ID:      id,
+				Message: fmt.Sprintf("broadcast %q %s", name, message),
+			})
+		}
+	}
+	return
+}
+
+/* broadcastKey identifies a broadcast by name, because broadcast menus and hat
+ * blocks do not always agree on IDs. Scratch compares broadcast names without
+ * regard to case.
+ */
+func broadcastKey (name string) (key string) {
+	return strings.ToLower(name)
+}
+
+/* usePrimitive records the variable, list, or broadcast that a primitive refers
+ * to as used.
+ */
+func usePrimitive (
+	primitive *sb3.Primitive,
+	use       func (kind, id, name string),
+	sent      map[string] bool,
+) {
+	switch primitive.Type {
+	case sb3.PrimitiveTypeVariable:
+		use("variable", primitive.ID, primitive.Name)
+	case sb3.PrimitiveTypeList:
+		use("list", primitive.ID, primitive.Name)
+	case sb3.PrimitiveTypeBroadcast:
+		use("broadcast", primitive.ID, primitive.Name)
+		sent[broadcastKey(primitive.Name)] = true
+	}
+}
+
+/* findUnreachable finds scripts in a target that can never run.
+ */
+func findUnreachable (target *sb3.Target) (findings []Finding) {
+	called := map[string] bool { }
+	for _, block := range target.Blocks {
+		if block.Opcode == "procedures_call" && block.Mutation != nil {
+			called[block.Mutation.ProcCode] = true
+		}
+	}
+	
+	for _, id := range target.Scripts() {
+		block := target.Blocks[id]
+		blockOpcode := opcode(block)
+		
+		message := ""
+		if blockOpcode == "procedures_definition" {
+			procCode := definitionProcCode(target, block)
+			if !called[procCode] {
+				message = fmt.Sprintf (
+					"custom block %q is never used",
+					procCode)
+			}
+		} else if !IsHat(blockOpcode) {
+			message = fmt.Sprintf (
+				"script starting with %s has no hat block",
+				blockOpcode)
+		}
+		if message == "" { continue }
+
+		findings = append(findings, Finding {
+			Kind:    FindingUnreachableScript,
+			Target:  target.Name,
+			ID:      id,
+			Message: message,
+		})
+	}
+	return
+}
+
+/* IsHat returns whether an opcode belongs to a hat block, which starts a script
+ * when something happens.
+ */
+func IsHat (opcode string) (hat bool) {
+	if opcode == "control_start_as_clone" { return true }
+	if opcode == "procedures_definition"  { return true }
+	_, event, _ := strings.Cut(opcode, "_")
+	return strings.HasPrefix(event, "when")
+}
+
+/* definitionProcCode returns the procedure code of a custom block definition,
+ * which is stored in the mutation of its prototype block.
+ */
+func definitionProcCode (
+	target     *sb3.Target,
+	definition *sb3.Block,
+) (
+	procCode string,
+) {
+	input, ok := definition.Inputs["custom_block"]
+	if !ok { return }
+	prototype := target.Blocks[input.Block.BlockID]
+	if prototype == nil || prototype.Mutation == nil { return }
+	return prototype.Mutation.ProcCode
+}
+
+// backdropMenuItems lists the special values of the backdrop menu, which do not
+// refer to a specific backdrop.
+var backdropMenuItems = map[string] bool {
+	"next backdrop":     true,
+	"previous backdrop": true,
+	"random backdrop":   true,
+}
+
+/* findMissingCostumes finds blocks in a target that switch to costumes or
+ * backdrops that do not exist.
+ */
+func findMissingCostumes (
+	project *sb3.Project,
+	target  *sb3.Target,
+) (
+	findings []Finding,
+) {
+	if len(target.Costumes) == 0 {
+		findings = append(findings, Finding {
+			Kind:    FindingMissingCostume,
+			Target:  target.Name,
+			Message: "target has no costumes",
+		})
+	}
+
+	stage := project.Stage()
+	for _, id := range sb3.SortedKeys(target.Blocks) {
+		block := target.Blocks[id]
+		if !block.Shadow { continue }
+
+		var owner *sb3.Target
+		var name  string
+		var kind  string
+		switch block.Opcode {
+		case "looks_costume":
+			owner = target
+			name  = block.Fields["COSTUME"].String()
+			kind  = "costume"
+		case "looks_backdrops":
+			owner = stage
+			name  = block.Fields["BACKDROP"].String()
+			kind  = "backdrop"
+			if backdropMenuItems[name] { continue }
+		default:
+			continue
+		}
+		if owner == nil || owner.Costume(name) != nil { continue }
+
+		findings = append(findings, Finding {
+			Kind:    FindingMissingCostume,
+			Target:  target.Name,
+			ID:      blockParent(block, id),
+			Message: fmt.Sprintf("%s %q does not exist", kind, name),
+		})
+	}
+	return
+}
+
+/* blockParent returns the ID of a block's parent, or the ID of the block itself
+ * if it has no parent.
+ */
+func blockParent (block *sb3.Block, id string) (parent string) {
+	if block.Parent != "" { return block.Parent }
+	return id
+}
+
+/* findUndefinedProcedures finds custom block calls in a target that have no
+ * matching definition.
+ */
+func findUndefinedProcedures (target *sb3.Target) (findings []Finding) {
+	defined := map[string] bool { }
+	for _, block := range target.Blocks {
+		if block.Opcode == "procedures_prototype" && block.Mutation != nil {
+			defined[block.Mutation.ProcCode] = true
+		}
+	}
+
+	for _, id := range sb3.SortedKeys(target.Blocks) {
+		block := target.Blocks[id]
+		if block.Opcode != "procedures_call" || block.Mutation == nil {
+			continue
+		}
+		if defined[block.Mutation.ProcCode] { continue }
+
+		findings = append(findings, Finding {
+			Kind:    FindingUndefinedProcedure,
+			Target:  target.Name,
+			ID:      id,
+			Message: fmt.Sprintf (
+				"custom block %q is not defined",
+				block.Mutation.ProcCode),
+		})
+	}
+	return
+}
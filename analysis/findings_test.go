@@ -0,0 +1,120 @@
+package analysis
+
+import "strings"
+import "testing"
+import "github.com/scapi3/sb3"
+
+func TestFindings (test *testing.T) {
+	cases := []struct {
+		name     string
+		stage    string
+		blocks   string
+		expected string
+	} {
+		{
+			"unused variable", `"variables":{"v":["score",0]}`, `{}`,
+			`Stage: unused-variable: variable "score" is never used`,
+		}, {
+			"unused list", `"lists":{"l":["items",[]]}`, `{}`,
+			`Stage: unused-list: list "items" is never used`,
+		}, {
+			"unused broadcast", `"broadcasts":{"b":"go"}`, `{}`,
+			`Stage: unused-broadcast: broadcast "go" is never used`,
+		}, {
+			"broadcast never received", `"broadcasts":{"b":"go"}`, `{
+				"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "event_broadcast", "next": null, "parent": "a", "inputs": {"BROADCAST_INPUT": [1, [11, "go", "b"]]}, "fields": {}, "shadow": false, "topLevel": false}
+			}`,
+			`Stage: unused-broadcast: broadcast "go" is never received`,
+		}, {
+			"broadcast never sent", `"broadcasts":{"b":"go"}`, `{
+				"a": {"opcode": "event_whenbroadcastreceived", "next": null, "parent": null, "inputs": {}, "fields": {"BROADCAST_OPTION": ["GO", "b"]}, "shadow": false, "topLevel": true, "x": 0, "y": 0}
+			}`,
+			`Stage: unused-broadcast: broadcast "go" is never sent`,
+		}, {
+			"script without a hat", "", `{
+				"a": {"opcode": "motion_movesteps", "next": null, "parent": null, "inputs": {"STEPS": [1, [4, "10"]]}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0}
+			}`,
+			`Cat: unreachable-script: script starting with motion_movesteps has no hat block`,
+		}, {
+			"unused custom block", "", `{
+				"a": {"opcode": "procedures_definition", "next": null, "parent": null, "inputs": {"custom_block": [1, "b"]}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "procedures_prototype", "next": null, "parent": "a", "inputs": {}, "fields": {}, "shadow": true, "topLevel": false, "mutation": {"tagName": "mutation", "children": [], "proccode": "jump", "argumentids": "[]", "argumentnames": "[]", "argumentdefaults": "[]", "warp": "false"}}
+			}`,
+			`Cat: unreachable-script: custom block "jump" is never used`,
+		}, {
+			"missing costume", "", `{
+				"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "looks_switchcostumeto", "next": null, "parent": "a", "inputs": {"COSTUME": [1, "c"]}, "fields": {}, "shadow": false, "topLevel": false},
+				"c": {"opcode": "looks_costume", "next": null, "parent": "b", "inputs": {}, "fields": {"COSTUME": ["ghost", null]}, "shadow": true, "topLevel": false}
+			}`,
+			`Cat: missing-costume: costume "ghost" does not exist`,
+		}, {
+			"missing backdrop", "", `{
+				"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "looks_switchbackdropto", "next": "d", "parent": "a", "inputs": {"BACKDROP": [1, "c"]}, "fields": {}, "shadow": false, "topLevel": false},
+				"c": {"opcode": "looks_backdrops", "next": null, "parent": "b", "inputs": {}, "fields": {"BACKDROP": ["night", null]}, "shadow": true, "topLevel": false},
+				"d": {"opcode": "looks_switchbackdropto", "next": null, "parent": "b", "inputs": {"BACKDROP": [1, "e"]}, "fields": {}, "shadow": false, "topLevel": false},
+				"e": {"opcode": "looks_backdrops", "next": null, "parent": "d", "inputs": {}, "fields": {"BACKDROP": ["next backdrop", null]}, "shadow": true, "topLevel": false}
+			}`,
+			`Cat: missing-costume: backdrop "night" does not exist`,
+		}, {
+			"undefined custom block", "", `{
+				"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "procedures_call", "next": null, "parent": "a", "inputs": {}, "fields": {}, "shadow": false, "topLevel": false, "mutation": {"tagName": "mutation", "children": [], "proccode": "missing", "argumentids": "[]", "warp": "false"}}
+			}`,
+			`Cat: undefined-procedure: custom block "missing" is not defined`,
+		}, {
+			"nothing wrong", `"variables":{"v":["score",0]}`, `{
+				"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+				"b": {"opcode": "motion_movesteps", "next": null, "parent": "a", "inputs": {"STEPS": [3, [12, "score", "v"], [4, "10"]]}, "fields": {}, "shadow": false, "topLevel": false}
+			}`,
+			"",
+		},
+	}
+
+	for _, testCase := range cases {
+		report := Analyze(parseProject(test, testCase.stage, testCase.blocks))
+		if findingsString(report.Findings) != testCase.expected {
+			test.Errorf (
+				"%s: findings are %q, expected %q", testCase.name,
+				findingsString(report.Findings), testCase.expected)
+		}
+	}
+}
+
+func TestFindingIDs (test *testing.T) {
+	project, err := sb3.Parse([]byte (
+		`{"targets":[{"isStage":true,"name":"Stage"},{"isStage":false,"name":"Cat","costumes":[` + costume + `],"blocks":{
+			"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+			"b": {"opcode": "looks_switchcostumeto", "next": null, "parent": "a", "inputs": {"COSTUME": [1, "c"]}, "fields": {}, "shadow": false, "topLevel": false},
+			"c": {"opcode": "looks_costume", "next": null, "parent": "b", "inputs": {}, "fields": {"COSTUME": ["ghost", null]}, "shadow": true, "topLevel": false}
+		}}]}`))
+	if err != nil { test.Fatal(err) }
+	findings := Analyze(project).Findings
+
+	// the stage has no costumes, and the missing costume is reported on the
+	// block that switches to it
+	expected := []Finding {
+		{ Kind: FindingMissingCostume, Target: "Stage", Message: "target has no costumes" },
+		{ Kind: FindingMissingCostume, Target: "Cat", ID: "b", Message: `costume "ghost" does not exist` },
+	}
+	if len(findings) != len(expected) {
+		test.Fatalf("findings are %+v, expected %+v", findings, expected)
+	}
+	for index, finding := range findings {
+		if finding != expected[index] {
+			test.Errorf("finding %d is %+v, expected %+v", index, finding, expected[index])
+		}
+	}
+}
+
+/* findingsString joins the descriptions of findings, one on each line.
+ */
+func findingsString (findings []Finding) (description string) {
+	descriptions := []string { }
+	for _, finding := range findings {
+		descriptions = append(descriptions, finding.String())
+	}
+	return strings.Join(descriptions, "\n")
+}
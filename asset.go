@@ -0,0 +1,266 @@
+package scapi3
+
+import "io"
+import "os"
+import "fmt"
+import "sync"
+import "strconv"
+import "net/url"
+import "net/http"
+import "path/filepath"
+import "github.com/scapi3/sb2"
+import "github.com/scapi3/sb3"
+
+// MaxConcurrentDownloads is the maximum amount of assets that DownloadProject
+// downloads at the same time.
+const MaxConcurrentDownloads = 8
+
+/* GetAsset downloads an asset from the Scratch asset server. The name must be
+ * the asset's MD5 hash followed by its file extension. The returned reader
+ * streams the contents of the asset from the server, and should be closed by
+ * the caller.
+ */
+func GetAsset (md5ext string) (reader io.ReadCloser, err error) {
+	response, err := Request {
+		Hostname: "assets.scratch.mit.edu",
+		Path:     assetPath(md5ext),
+	}.Stream()
+	if err != nil { return }
+	
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf (
+			"cannot get asset %s (%s)",
+			md5ext, response.Status)
+	}
+	return response.Body, nil
+}
+
+/* assetPath returns the path that an asset is downloaded from on the asset
+ * server.
+ */
+func assetPath (md5ext string) (path string) {
+	return "/internalapi/asset/" + url.PathEscape(md5ext) + "/get/"
+}
+
+/* AssetCache stores downloaded assets in a directory on disk. Because assets
+ * are named after the MD5 hash of their contents, an asset that is shared
+ * between projects is only downloaded once.
+ */
+type AssetCache struct {
+	Dir string
+}
+
+/* NewAssetCache creates an asset cache that stores assets in the specified
+ * directory, creating it if it does not exist.
+ */
+func NewAssetCache (dir string) (cache *AssetCache, err error) {
+	err = os.MkdirAll(dir, 0755)
+	if err != nil { return }
+	return &AssetCache { Dir: dir }, nil
+}
+
+/* Get returns the contents of an asset, downloading it and storing it in the
+ * cache if it is not already there.
+ */
+func (cache *AssetCache) Get (md5ext string) (data []byte, err error) {
+	cachePath := filepath.Join(cache.Dir, filepath.Base(md5ext))
+	data, err = os.ReadFile(cachePath)
+	if err == nil && sb3.VerifyAsset(md5ext, data) == nil { return }
+	
+	data, err = downloadAsset(md5ext)
+	if err != nil { return }
+
+	// write to a temporary file first so that a partially written asset is
+	// never read back
+	file, err := os.CreateTemp(cache.Dir, ".download-*")
+	if err != nil { return }
+	_, err = file.Write(data)
+	closeErr := file.Close()
+	if err == nil { err = closeErr }
+	if err == nil { err = os.Rename(file.Name(), cachePath) }
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return
+}
+
+/* DownloadProject downloads a shared project along with all of its costumes and
+ * sounds, and writes it to writer as an sb3 file. If cache is not nil, assets
+ * are read from and stored in it. See UserSession.DownloadProject.
+ */
+func DownloadProject (
+	id     uint64,
+	writer io.Writer,
+	cache  *AssetCache,
+) (
+	report sb2.Report,
+	err    error,
+) {
+	return anonymous.DownloadProject(id, writer, cache)
+}
+
+/* DownloadProjectFile downloads a shared project and saves it as an sb3 file
+ * named after its ID in the specified directory. The path of the file is
+ * returned. See DownloadProject.
+ */
+func DownloadProjectFile (
+	id    uint64,
+	dir   string,
+	cache *AssetCache,
+) (
+	path   string,
+	report sb2.Report,
+	err    error,
+) {
+	return anonymous.DownloadProjectFile(id, dir, cache)
+}
+
+/* DownloadProject downloads a project along with all of its costumes and
+ * sounds, and writes it to writer as an sb3 file. Unshared projects owned by the
+ * session's user can be downloaded as well, and Scratch 2 projects are converted
+ * to sb3. If cache is not nil, assets are read from and stored in it.
+ *
+ * The report of the conversion is returned, and is empty if the project did not
+ * need to be converted. If it is not empty, the project is still written, but
+ * it is missing the parts listed in the report.
+ */
+func (session *UserSession) DownloadProject (
+	id     uint64,
+	writer io.Writer,
+	cache  *AssetCache,
+) (
+	report sb2.Report,
+	err    error,
+) {
+	project, report, err := session.getSB3Project(id)
+	if err != nil {
+		err = fmt.Errorf("cannot download project %d: %v", id, err)
+		return
+	}
+
+	assets, err := downloadAssets(sb3.ReferencedAssets(project), cache)
+	if err != nil {
+		err = fmt.Errorf("cannot download project %d: %v", id, err)
+		return
+	}
+	err = sb3.Write(writer, project, assets)
+	return
+}
+
+/* DownloadProjectFile downloads a project and saves it as an sb3 file named
+ * after its ID in the specified directory. The path of the file is returned.
+ * See DownloadProject.
+ */
+func (session *UserSession) DownloadProjectFile (
+	id    uint64,
+	dir   string,
+	cache *AssetCache,
+) (
+	path   string,
+	report sb2.Report,
+	err    error,
+) {
+	path = filepath.Join(dir, strconv.FormatUint(id, 10) + ".sb3")
+	file, err := os.Create(path)
+	if err != nil { return "", report, err }
+
+	report, err = session.DownloadProject(id, file, cache)
+	closeErr := file.Close()
+	if err == nil { err = closeErr }
+	if err != nil {
+		os.Remove(path)
+		return "", report, err
+	}
+	return
+}
+
+/* getSB3Project downloads the body of a project and parses it. Scratch 2
+ * projects are converted to the Scratch 3 format, and other formats fail. If
+ * the project was converted, the conversion report is returned as well, and
+ * is empty otherwise.
+ */
+func (session *UserSession) getSB3Project (
+	id uint64,
+) (
+	project *sb3.Project,
+	report  sb2.Report,
+	err     error,
+) {
+	raw, projectJSON, err := session.GetProjectJSON(id)
+	if err != nil { return }
+	if projectJSON.Format == ProjectFormatSB2 {
+		legacy, err := sb2.Parse(raw)
+		if err != nil { return nil, report, err }
+		project, _, report, err = sb2.Convert(legacy, nil)
+		return project, report, err
+	}
+	if projectJSON.Format != ProjectFormatSB3 {
+		err = fmt.Errorf (
+			"project %d is in %v format",
+			id, projectJSON.Format)
+		return
+	}
+	project, err = sb3.Parse(raw)
+	return
+}
+
+/* downloadAssets downloads several assets at once, and returns them in a map
+ * keyed by their names.
+ */
+func downloadAssets (
+	names []string,
+	cache *AssetCache,
+) (
+	assets map[string] []byte,
+	err    error,
+) {
+	assets = make(map[string] []byte)
+	lock      := sync.Mutex { }
+	group     := sync.WaitGroup { }
+	semaphore := make(chan struct { }, MaxConcurrentDownloads)
+
+	for _, name := range names {
+		group.Add(1)
+		semaphore <- struct { } { }
+		go func (name string) {
+			defer group.Done()
+			defer func () { <- semaphore } ()
+
+			var data []byte
+			var downloadErr error
+			if cache == nil {
+				data, downloadErr = downloadAsset(name)
+			} else {
+				data, downloadErr = cache.Get(name)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if downloadErr != nil {
+				if err == nil { err = downloadErr }
+				return
+			}
+			assets[name] = data
+		} (name)
+	}
+
+	group.Wait()
+	if err != nil { return nil, err }
+	return
+}
+
+/* downloadAsset downloads the entire contents of an asset, and checks that they
+ * match its MD5 hash.
+ */
+func downloadAsset (md5ext string) (data []byte, err error) {
+	reader, err := GetAsset(md5ext)
+	if err != nil { return }
+	defer reader.Close()
+	
+	data, err = io.ReadAll(reader)
+	if err != nil { return }
+	err = sb3.VerifyAsset(md5ext, data)
+	return
+}
@@ -0,0 +1,153 @@
+package scapi3
+
+import "io"
+import "os"
+import "bytes"
+import "strings"
+import "time"
+import "testing"
+import "net/http"
+import "path/filepath"
+import "net/http/httptest"
+import "github.com/scapi3/sb3"
+
+// sb2Project is a Scratch 2 project with a block that cannot be converted.
+const sb2Project = `{
+	"objName": "Stage",
+	"scripts": [[0, 0, [["whenGreenFlag"], ["playDrum", 1, 0.25], ["forward:", 10]]]],
+	"children": []
+}`
+
+/* newProjectServer starts a stand-in for the API and projects servers, which
+ * serves the specified project bodies, and points BaseURL at it until the test
+ * ends.
+ */
+func newProjectServer (test *testing.T, projects map[string] string) {
+	server := httptest.NewServer(http.HandlerFunc (
+		func (writer http.ResponseWriter, request *http.Request) {
+			host, path, _ := strings.Cut(strings.TrimPrefix(request.URL.Path, "/"), "/")
+			switch host {
+			case "api.scratch.mit.edu":
+				id := strings.TrimPrefix(path, "projects/")
+				if _, ok := projects[id]; !ok { break }
+				writer.Write([]byte (
+					`{"id":` + id + `,"project_token":"token` + id + `"}`))
+				return
+			case "projects.scratch.mit.edu":
+				if request.URL.Query().Get("token") != "token" + path { break }
+				writer.Write([]byte(projects[path]))
+				return
+			}
+			writer.WriteHeader(http.StatusNotFound)
+		}))
+	previous := BaseURL
+	BaseURL = func (hostname string) string {
+		return server.URL + "/" + hostname
+	}
+	test.Cleanup(func () {
+		BaseURL = previous
+		server.Close()
+	})
+}
+
+func TestDownloadAsset (test *testing.T) {
+	data := []byte(`<svg width="10" height="10"/>`)
+	name := sb3.AssetName(data, "svg")
+	server := httptest.NewServer(http.HandlerFunc (
+		func (writer http.ResponseWriter, request *http.Request) {
+			switch request.URL.Path {
+			case "/assets.scratch.mit.edu/internalapi/asset/" + name + "/get/":
+				writer.Write(data)
+			case "/assets.scratch.mit.edu/internalapi/asset/bad.svg/get/":
+				writer.Write([]byte("corrupt"))
+			default:
+				writer.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	previous := BaseURL
+	BaseURL = func (hostname string) string {
+		return server.URL + "/" + hostname
+	}
+	test.Cleanup(func () {
+		BaseURL = previous
+		server.Close()
+	})
+
+	downloaded, err := downloadAsset(name)
+	if err != nil { test.Fatal(err) }
+	if string(downloaded) != string(data) {
+		test.Fatalf("downloaded %q, expected %q", downloaded, data)
+	}
+
+	_, err = downloadAsset("bad.svg")
+	if err == nil { test.Fatal("asset with the wrong hash was accepted") }
+	_, err = GetAsset("missing.svg")
+	if err == nil { test.Fatal("missing asset was downloaded") }
+}
+
+func TestGetAssetStreams (test *testing.T) {
+	release := make(chan struct { })
+	server  := httptest.NewServer(http.HandlerFunc (
+		func (writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte("first "))
+			writer.(http.Flusher).Flush()
+			
+			// the rest is only sent once the client has read the start,
+			// which it cannot do if the whole body is read up front
+			select {
+			case <- release:
+				writer.Write([]byte("second"))
+			case <- time.After(5 * time.Second):
+				writer.Write([]byte("timed out"))
+			}
+		}))
+	previous := BaseURL
+	BaseURL = func (hostname string) string { return server.URL }
+	test.Cleanup(func () {
+		BaseURL = previous
+		server.Close()
+	})
+
+	reader, err := GetAsset("streamed.wav")
+	if err != nil { test.Fatal(err) }
+	defer reader.Close()
+	start := make([]byte, 6)
+	_, err = io.ReadFull(reader, start)
+	if err != nil { test.Fatal(err) }
+	close(release)
+	rest, err := io.ReadAll(reader)
+	if err != nil { test.Fatal(err) }
+	if string(start) + string(rest) != "first second" {
+		test.Fatalf("asset is %q", string(start) + string(rest))
+	}
+}
+
+func TestDownloadConvertedProject (test *testing.T) {
+	newProjectServer(test, map[string] string { "7": sb2Project })
+
+	buffer := bytes.Buffer { }
+	report, err := DownloadProject(7, &buffer, nil)
+	if err != nil { test.Fatal(err) }
+	if report.Unconverted["playDrum"] != 1 {
+		test.Fatalf("report is %q, expected playDrum", report)
+	}
+
+	// the project is still written, without the unconverted block
+	archive, err := sb3.Open(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil { test.Fatal(err) }
+	stage := archive.Project.Stage()
+	if len(stage.Blocks) != 2 {
+		test.Fatalf("project has %d blocks, expected 2", len(stage.Blocks))
+	}
+
+	dir := test.TempDir()
+	path, report, err := DownloadProjectFile(7, dir, nil)
+	if err != nil { test.Fatal(err) }
+	if report.Empty() { test.Fatal("report of the saved project is empty") }
+	if path != filepath.Join(dir, "7.sb3") {
+		test.Fatalf("project was saved to %q", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		test.Fatalf("project file was not kept: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package builder
+
+import "github.com/scapi3/sb3"
+
+/* inputKind describes what an input of a block holds.
+ */
+type inputKind int
+
+const (
+	inputValue inputKind = iota
+	inputMenu
+	inputCondition
+	inputSubstack
+
+	// inputShadow holds a shadow block, and is used by custom block
+	// prototypes.
+	inputShadow
+)
+
+/* blockInput is an input of a block that has not been added to a sprite yet.
+ */
+type blockInput struct {
+	name  string
+	kind  inputKind
+	value any
+
+	// primitive is the type of shadow used by value inputs.
+	primitive sb3.PrimitiveType
+
+	// menuOpcode and menuField describe the shadow block used by menu
+	// inputs.
+	menuOpcode string
+	menuField  string
+
+	// blocks is the stack inside of a substack input.
+	blocks []*Block
+}
+
+/* Block is a block that has not been added to a sprite yet. Blocks are turned
+ * into project blocks when the script they are in is added to a sprite, so the
+ * same Block can be used in several places.
+ *
+ * Values given to inputs can be strings, numbers, booleans, blocks, variables,
+ * lists, or broadcasts. Values given to fields can be strings, variables,
+ * lists, or broadcasts.
+ */
+type Block struct {
+	opcode   string
+	inputs   []blockInput
+	fields   map[string] any
+	shadow   bool
+	mutation *sb3.Mutation
+}
+
+/* NewBlock creates a block with the specified opcode and no inputs or fields.
+ * It can be used to create blocks that this package has no function for.
+ */
+func NewBlock (opcode string) (block *Block) {
+	return &Block {
+		opcode: opcode,
+		fields: map[string] any { },
+	}
+}
+
+/* Opcode returns the opcode of the block.
+ */
+func (block *Block) Opcode () (opcode string) {
+	return block.opcode
+}
+
+/* Input sets an input that holds a value. If the value is a literal, it is
+ * stored in a shadow of the specified type. Otherwise, it covers up an empty
+ * shadow of that type.
+ */
+func (block *Block) Input (
+	name      string,
+	primitive sb3.PrimitiveType,
+	value     any,
+) (
+	self *Block,
+) {
+	return block.setInput(blockInput {
+		name:      name,
+		kind:      inputValue,
+		value:     value,
+		primitive: primitive,
+	})
+}
+
+/* Menu sets an input that holds a menu, such as the costume menu of "switch
+ * costume to". If the value is a literal, it is selected in a shadow block
+ * with the specified opcode and field name. If it is a block, it covers up the
+ * menu.
+ */
+func (block *Block) Menu (
+	name   string,
+	opcode string,
+	field  string,
+	value  any,
+) (
+	self *Block,
+) {
+	return block.setInput(blockInput {
+		name:       name,
+		kind:       inputMenu,
+		value:      value,
+		menuOpcode: opcode,
+		menuField:  field,
+	})
+}
+
+/* Condition sets a boolean input. If condition is nil, the input is left
+ * empty.
+ */
+func (block *Block) Condition (name string, condition *Block) (self *Block) {
+	return block.setInput(blockInput {
+		name:  name,
+		kind:  inputCondition,
+		value: condition,
+	})
+}
+
+/* Substack sets the stack of blocks inside of a C block.
+ */
+func (block *Block) Substack (name string, blocks ...*Block) (self *Block) {
+	return block.setInput(blockInput {
+		name:   name,
+		kind:   inputSubstack,
+		blocks: blocks,
+	})
+}
+
+/* Field sets a field of the block, such as a dropdown that cannot hold other
+ * blocks.
+ */
+func (block *Block) Field (name string, value any) (self *Block) {
+	block.fields[name] = value
+	return block
+}
+
+/* Mutation sets the mutation of the block.
+ */
+func (block *Block) Mutation (mutation *sb3.Mutation) (self *Block) {
+	block.mutation = mutation
+	return block
+}
+
+/* setInput sets an input, replacing any input with the same name.
+ */
+func (block *Block) setInput (input blockInput) (self *Block) {
+	for index, existing := range block.inputs {
+		if existing.name == input.name {
+			block.inputs[index] = input
+			return block
+		}
+	}
+	block.inputs = append(block.inputs, input)
+	return block
+}
@@ -0,0 +1,627 @@
+package builder
+
+import "github.com/scapi3/sb3"
+
+// shorter names for the primitive types used by inputs
+const (
+	number   = sb3.PrimitiveTypeMathNumber
+	positive = sb3.PrimitiveTypePositiveNumber
+	whole    = sb3.PrimitiveTypeWholeNumber
+	integer  = sb3.PrimitiveTypeInteger
+	angle    = sb3.PrimitiveTypeAngle
+	text     = sb3.PrimitiveTypeText
+)
+
+/* WhenFlagClicked creates a "when green flag clicked" block.
+ */
+func WhenFlagClicked () (block *Block) {
+	return NewBlock("event_whenflagclicked")
+}
+
+/* WhenKeyPressed creates a "when key pressed" block. Key is the name of a key,
+ * such as "space" or "a", or "any".
+ */
+func WhenKeyPressed (key string) (block *Block) {
+	return NewBlock("event_whenkeypressed").Field("KEY_OPTION", key)
+}
+
+/* WhenThisSpriteClicked creates a "when this sprite clicked" block.
+ */
+func WhenThisSpriteClicked () (block *Block) {
+	return NewBlock("event_whenthisspriteclicked")
+}
+
+/* WhenStageClicked creates a "when stage clicked" block.
+ */
+func WhenStageClicked () (block *Block) {
+	return NewBlock("event_whenstageclicked")
+}
+
+/* WhenReceived creates a "when I receive" block.
+ */
+func WhenReceived (broadcast *Broadcast) (block *Block) {
+	return NewBlock("event_whenbroadcastreceived").
+		Field("BROADCAST_OPTION", broadcast)
+}
+
+/* WhenStartAsClone creates a "when I start as a clone" block.
+ */
+func WhenStartAsClone () (block *Block) {
+	return NewBlock("control_start_as_clone")
+}
+
+/* SendBroadcast creates a "broadcast" block. Message is a broadcast, or a
+ * block that reports the name of one.
+ */
+func SendBroadcast (message any) (block *Block) {
+	return NewBlock("event_broadcast").
+		Input("BROADCAST_INPUT", sb3.PrimitiveTypeBroadcast, message)
+}
+
+/* SendBroadcastAndWait creates a "broadcast and wait" block. See
+ * SendBroadcast.
+ */
+func SendBroadcastAndWait (message any) (block *Block) {
+	return NewBlock("event_broadcastandwait").
+		Input("BROADCAST_INPUT", sb3.PrimitiveTypeBroadcast, message)
+}
+
+/* MoveSteps creates a "move steps" block.
+ */
+func MoveSteps (steps any) (block *Block) {
+	return NewBlock("motion_movesteps").Input("STEPS", number, steps)
+}
+
+/* TurnRight creates a "turn right degrees" block.
+ */
+func TurnRight (degrees any) (block *Block) {
+	return NewBlock("motion_turnright").Input("DEGREES", number, degrees)
+}
+
+/* TurnLeft creates a "turn left degrees" block.
+ */
+func TurnLeft (degrees any) (block *Block) {
+	return NewBlock("motion_turnleft").Input("DEGREES", number, degrees)
+}
+
+/* GoTo creates a "go to" block. Target is the name of a sprite,
+ * "_random_", or "_mouse_".
+ */
+func GoTo (target any) (block *Block) {
+	return NewBlock("motion_goto").
+		Menu("TO", "motion_goto_menu", "TO", target)
+}
+
+/* GoToXY creates a "go to x y" block.
+ */
+func GoToXY (x, y any) (block *Block) {
+	return NewBlock("motion_gotoxy").
+		Input("X", number, x).
+		Input("Y", number, y)
+}
+
+/* GlideToXY creates a "glide secs to x y" block.
+ */
+func GlideToXY (seconds, x, y any) (block *Block) {
+	return NewBlock("motion_glidesecstoxy").
+		Input("SECS", number, seconds).
+		Input("X",    number, x).
+		Input("Y",    number, y)
+}
+
+/* PointInDirection creates a "point in direction" block.
+ */
+func PointInDirection (direction any) (block *Block) {
+	return NewBlock("motion_pointindirection").
+		Input("DIRECTION", angle, direction)
+}
+
+/* ChangeXBy creates a "change x by" block.
+ */
+func ChangeXBy (dx any) (block *Block) {
+	return NewBlock("motion_changexby").Input("DX", number, dx)
+}
+
+/* SetX creates a "set x to" block.
+ */
+func SetX (x any) (block *Block) {
+	return NewBlock("motion_setx").Input("X", number, x)
+}
+
+/* ChangeYBy creates a "change y by" block.
+ */
+func ChangeYBy (dy any) (block *Block) {
+	return NewBlock("motion_changeyby").Input("DY", number, dy)
+}
+
+/* SetY creates a "set y to" block.
+ */
+func SetY (y any) (block *Block) {
+	return NewBlock("motion_sety").Input("Y", number, y)
+}
+
+/* IfOnEdgeBounce creates an "if on edge, bounce" block.
+ */
+func IfOnEdgeBounce () (block *Block) {
+	return NewBlock("motion_ifonedgebounce")
+}
+
+/* XPosition creates an "x position" reporter.
+ */
+func XPosition () (block *Block) {
+	return NewBlock("motion_xposition")
+}
+
+/* YPosition creates a "y position" reporter.
+ */
+func YPosition () (block *Block) {
+	return NewBlock("motion_yposition")
+}
+
+/* Direction creates a "direction" reporter.
+ */
+func Direction () (block *Block) {
+	return NewBlock("motion_direction")
+}
+
+/* Say creates a "say" block.
+ */
+func Say (message any) (block *Block) {
+	return NewBlock("looks_say").Input("MESSAGE", text, message)
+}
+
+/* SayForSeconds creates a "say for secs" block.
+ */
+func SayForSeconds (message, seconds any) (block *Block) {
+	return NewBlock("looks_sayforsecs").
+		Input("MESSAGE", text,   message).
+		Input("SECS",    number, seconds)
+}
+
+/* Think creates a "think" block.
+ */
+func Think (message any) (block *Block) {
+	return NewBlock("looks_think").Input("MESSAGE", text, message)
+}
+
+/* ThinkForSeconds creates a "think for secs" block.
+ */
+func ThinkForSeconds (message, seconds any) (block *Block) {
+	return NewBlock("looks_thinkforsecs").
+		Input("MESSAGE", text,   message).
+		Input("SECS",    number, seconds)
+}
+
+/* SwitchCostumeTo creates a "switch costume to" block.
+ */
+func SwitchCostumeTo (costume any) (block *Block) {
+	return NewBlock("looks_switchcostumeto").
+		Menu("COSTUME", "looks_costume", "COSTUME", costume)
+}
+
+/* NextCostume creates a "next costume" block.
+ */
+func NextCostume () (block *Block) {
+	return NewBlock("looks_nextcostume")
+}
+
+/* SwitchBackdropTo creates a "switch backdrop to" block.
+ */
+func SwitchBackdropTo (backdrop any) (block *Block) {
+	return NewBlock("looks_switchbackdropto").
+		Menu("BACKDROP", "looks_backdrops", "BACKDROP", backdrop)
+}
+
+/* NextBackdrop creates a "next backdrop" block.
+ */
+func NextBackdrop () (block *Block) {
+	return NewBlock("looks_nextbackdrop")
+}
+
+/* ChangeSizeBy creates a "change size by" block.
+ */
+func ChangeSizeBy (change any) (block *Block) {
+	return NewBlock("looks_changesizeby").Input("CHANGE", number, change)
+}
+
+/* SetSizeTo creates a "set size to %" block.
+ */
+func SetSizeTo (size any) (block *Block) {
+	return NewBlock("looks_setsizeto").Input("SIZE", number, size)
+}
+
+/* Show creates a "show" block.
+ */
+func Show () (block *Block) {
+	return NewBlock("looks_show")
+}
+
+/* Hide creates a "hide" block.
+ */
+func Hide () (block *Block) {
+	return NewBlock("looks_hide")
+}
+
+/* StartSound creates a "start sound" block.
+ */
+func StartSound (sound any) (block *Block) {
+	return NewBlock("sound_play").
+		Menu("SOUND_MENU", "sound_sounds_menu", "SOUND_MENU", sound)
+}
+
+/* PlaySoundUntilDone creates a "play sound until done" block.
+ */
+func PlaySoundUntilDone (sound any) (block *Block) {
+	return NewBlock("sound_playuntildone").
+		Menu("SOUND_MENU", "sound_sounds_menu", "SOUND_MENU", sound)
+}
+
+/* StopAllSounds creates a "stop all sounds" block.
+ */
+func StopAllSounds () (block *Block) {
+	return NewBlock("sound_stopallsounds")
+}
+
+/* Wait creates a "wait seconds" block.
+ */
+func Wait (seconds any) (block *Block) {
+	return NewBlock("control_wait").Input("DURATION", positive, seconds)
+}
+
+/* Repeat creates a "repeat" block containing the specified blocks.
+ */
+func Repeat (times any, blocks ...*Block) (block *Block) {
+	return NewBlock("control_repeat").
+		Input("TIMES", whole, times).
+		Substack("SUBSTACK", blocks...)
+}
+
+/* Forever creates a "forever" block containing the specified blocks.
+ */
+func Forever (blocks ...*Block) (block *Block) {
+	return NewBlock("control_forever").Substack("SUBSTACK", blocks...)
+}
+
+/* If creates an "if then" block containing the specified blocks.
+ */
+func If (condition *Block, blocks ...*Block) (block *Block) {
+	return NewBlock("control_if").
+		Condition("CONDITION", condition).
+		Substack("SUBSTACK", blocks...)
+}
+
+/* IfElse creates an "if then else" block.
+ */
+func IfElse (condition *Block, then, otherwise []*Block) (block *Block) {
+	return NewBlock("control_if_else").
+		Condition("CONDITION", condition).
+		Substack("SUBSTACK",  then...).
+		Substack("SUBSTACK2", otherwise...)
+}
+
+/* WaitUntil creates a "wait until" block.
+ */
+func WaitUntil (condition *Block) (block *Block) {
+	return NewBlock("control_wait_until").Condition("CONDITION", condition)
+}
+
+/* RepeatUntil creates a "repeat until" block containing the specified blocks.
+ */
+func RepeatUntil (condition *Block, blocks ...*Block) (block *Block) {
+	return NewBlock("control_repeat_until").
+		Condition("CONDITION", condition).
+		Substack("SUBSTACK", blocks...)
+}
+
+/* Stop creates a "stop" block. Option is "all", "this script", or "other
+ * scripts in sprite".
+ */
+func Stop (option string) (block *Block) {
+	hasNext := "false"
+	if option == "other scripts in sprite" { hasNext = "true" }
+	return NewBlock("control_stop").
+		Field("STOP_OPTION", option).
+		Mutation(&sb3.Mutation {
+			TagName: "mutation",
+			HasNext: hasNext,
+		})
+}
+
+/* CreateCloneOf creates a "create clone of" block. Target is the name of a
+ * sprite, or "_myself_".
+ */
+func CreateCloneOf (target any) (block *Block) {
+	return NewBlock("control_create_clone_of").Menu (
+		"CLONE_OPTION", "control_create_clone_of_menu", "CLONE_OPTION",
+		target)
+}
+
+/* DeleteThisClone creates a "delete this clone" block.
+ */
+func DeleteThisClone () (block *Block) {
+	return NewBlock("control_delete_this_clone")
+}
+
+/* TouchingObject creates a "touching" boolean. Object is the name of a
+ * sprite, "_mouse_", or "_edge_".
+ */
+func TouchingObject (object any) (block *Block) {
+	return NewBlock("sensing_touchingobject").Menu (
+		"TOUCHINGOBJECTMENU", "sensing_touchingobjectmenu",
+		"TOUCHINGOBJECTMENU", object)
+}
+
+/* KeyPressed creates a "key pressed" boolean.
+ */
+func KeyPressed (key any) (block *Block) {
+	return NewBlock("sensing_keypressed").
+		Menu("KEY_OPTION", "sensing_keyoptions", "KEY_OPTION", key)
+}
+
+/* MouseDown creates a "mouse down" boolean.
+ */
+func MouseDown () (block *Block) {
+	return NewBlock("sensing_mousedown")
+}
+
+/* MouseX creates a "mouse x" reporter.
+ */
+func MouseX () (block *Block) {
+	return NewBlock("sensing_mousex")
+}
+
+/* MouseY creates a "mouse y" reporter.
+ */
+func MouseY () (block *Block) {
+	return NewBlock("sensing_mousey")
+}
+
+/* AskAndWait creates an "ask and wait" block.
+ */
+func AskAndWait (question any) (block *Block) {
+	return NewBlock("sensing_askandwait").Input("QUESTION", text, question)
+}
+
+/* Answer creates an "answer" reporter.
+ */
+func Answer () (block *Block) {
+	return NewBlock("sensing_answer")
+}
+
+/* Timer creates a "timer" reporter.
+ */
+func Timer () (block *Block) {
+	return NewBlock("sensing_timer")
+}
+
+/* ResetTimer creates a "reset timer" block.
+ */
+func ResetTimer () (block *Block) {
+	return NewBlock("sensing_resettimer")
+}
+
+/* Add creates a "+" reporter.
+ */
+func Add (left, right any) (block *Block) {
+	return operator("operator_add", "NUM", number, left, right)
+}
+
+/* Subtract creates a "-" reporter.
+ */
+func Subtract (left, right any) (block *Block) {
+	return operator("operator_subtract", "NUM", number, left, right)
+}
+
+/* Multiply creates a "*" reporter.
+ */
+func Multiply (left, right any) (block *Block) {
+	return operator("operator_multiply", "NUM", number, left, right)
+}
+
+/* Divide creates a "/" reporter.
+ */
+func Divide (left, right any) (block *Block) {
+	return operator("operator_divide", "NUM", number, left, right)
+}
+
+/* Mod creates a "mod" reporter.
+ */
+func Mod (left, right any) (block *Block) {
+	return operator("operator_mod", "NUM", number, left, right)
+}
+
+/* PickRandom creates a "pick random to" reporter.
+ */
+func PickRandom (from, to any) (block *Block) {
+	return NewBlock("operator_random").
+		Input("FROM", number, from).
+		Input("TO",   number, to)
+}
+
+/* GreaterThan creates a ">" boolean.
+ */
+func GreaterThan (left, right any) (block *Block) {
+	return operator("operator_gt", "OPERAND", text, left, right)
+}
+
+/* LessThan creates a "<" boolean.
+ */
+func LessThan (left, right any) (block *Block) {
+	return operator("operator_lt", "OPERAND", text, left, right)
+}
+
+/* Equals creates a "=" boolean.
+ */
+func Equals (left, right any) (block *Block) {
+	return operator("operator_equals", "OPERAND", text, left, right)
+}
+
+/* And creates an "and" boolean.
+ */
+func And (left, right *Block) (block *Block) {
+	return NewBlock("operator_and").
+		Condition("OPERAND1", left).
+		Condition("OPERAND2", right)
+}
+
+/* Or creates an "or" boolean.
+ */
+func Or (left, right *Block) (block *Block) {
+	return NewBlock("operator_or").
+		Condition("OPERAND1", left).
+		Condition("OPERAND2", right)
+}
+
+/* Not creates a "not" boolean.
+ */
+func Not (operand *Block) (block *Block) {
+	return NewBlock("operator_not").Condition("OPERAND", operand)
+}
+
+/* Join creates a "join" reporter.
+ */
+func Join (left, right any) (block *Block) {
+	return operator("operator_join", "STRING", text, left, right)
+}
+
+/* LetterOf creates a "letter of" reporter.
+ */
+func LetterOf (index, value any) (block *Block) {
+	return NewBlock("operator_letter_of").
+		Input("LETTER", whole, index).
+		Input("STRING", text,  value)
+}
+
+/* LengthOf creates a "length of" reporter for text.
+ */
+func LengthOf (value any) (block *Block) {
+	return NewBlock("operator_length").Input("STRING", text, value)
+}
+
+/* Contains creates a "contains" boolean for text.
+ */
+func Contains (value, part any) (block *Block) {
+	return operator("operator_contains", "STRING", text, value, part)
+}
+
+/* Round creates a "round" reporter.
+ */
+func Round (value any) (block *Block) {
+	return NewBlock("operator_round").Input("NUM", number, value)
+}
+
+/* MathOp creates a reporter for a math function, such as "abs", "sqrt", or
+ * "sin".
+ */
+func MathOp (function string, value any) (block *Block) {
+	return NewBlock("operator_mathop").
+		Field("OPERATOR", function).
+		Input("NUM", number, value)
+}
+
+/* SetVariable creates a "set variable to" block.
+ */
+func SetVariable (variable *Variable, value any) (block *Block) {
+	return NewBlock("data_setvariableto").
+		Field("VARIABLE", variable).
+		Input("VALUE", text, value)
+}
+
+/* ChangeVariable creates a "change variable by" block.
+ */
+func ChangeVariable (variable *Variable, value any) (block *Block) {
+	return NewBlock("data_changevariableby").
+		Field("VARIABLE", variable).
+		Input("VALUE", number, value)
+}
+
+/* ShowVariable creates a "show variable" block.
+ */
+func ShowVariable (variable *Variable) (block *Block) {
+	return NewBlock("data_showvariable").Field("VARIABLE", variable)
+}
+
+/* HideVariable creates a "hide variable" block.
+ */
+func HideVariable (variable *Variable) (block *Block) {
+	return NewBlock("data_hidevariable").Field("VARIABLE", variable)
+}
+
+/* AddToList creates an "add to list" block.
+ */
+func AddToList (list *List, item any) (block *Block) {
+	return NewBlock("data_addtolist").
+		Field("LIST", list).
+		Input("ITEM", text, item)
+}
+
+/* DeleteOfList creates a "delete of list" block.
+ */
+func DeleteOfList (list *List, index any) (block *Block) {
+	return NewBlock("data_deleteoflist").
+		Field("LIST", list).
+		Input("INDEX", integer, index)
+}
+
+/* DeleteAllOfList creates a "delete all of list" block.
+ */
+func DeleteAllOfList (list *List) (block *Block) {
+	return NewBlock("data_deletealloflist").Field("LIST", list)
+}
+
+/* InsertAtList creates an "insert at list" block.
+ */
+func InsertAtList (list *List, index, item any) (block *Block) {
+	return NewBlock("data_insertatlist").
+		Field("LIST", list).
+		Input("ITEM",  text,    item).
+		Input("INDEX", integer, index)
+}
+
+/* ReplaceItemOfList creates a "replace item of list with" block.
+ */
+func ReplaceItemOfList (list *List, index, item any) (block *Block) {
+	return NewBlock("data_replaceitemoflist").
+		Field("LIST", list).
+		Input("INDEX", integer, index).
+		Input("ITEM",  text,    item)
+}
+
+/* ItemOfList creates an "item of list" reporter.
+ */
+func ItemOfList (list *List, index any) (block *Block) {
+	return NewBlock("data_itemoflist").
+		Field("LIST", list).
+		Input("INDEX", integer, index)
+}
+
+/* LengthOfList creates a "length of list" reporter.
+ */
+func LengthOfList (list *List) (block *Block) {
+	return NewBlock("data_lengthoflist").Field("LIST", list)
+}
+
+/* ListContains creates a "list contains" boolean.
+ */
+func ListContains (list *List, item any) (block *Block) {
+	return NewBlock("data_listcontainsitem").
+		Field("LIST", list).
+		Input("ITEM", text, item)
+}
+
+/* operator creates a block with two inputs named with a common prefix followed
+ * by 1 and 2, which is how Scratch names the inputs of most operators.
+ */
+func operator (
+	opcode    string,
+	prefix    string,
+	primitive sb3.PrimitiveType,
+	left      any,
+	right     any,
+) (
+	block *Block,
+) {
+	return NewBlock(opcode).
+		Input(prefix + "1", primitive, left).
+		Input(prefix + "2", primitive, right)
+}
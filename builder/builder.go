@@ -0,0 +1,358 @@
+/* Package builder creates Scratch 3 projects from Go code. Sprites, variables,
+ * lists, broadcasts, and scripts are added to a Project through a fluent API,
+ * and the builder takes care of block IDs, the links between blocks, and the
+ * shadow blocks that hold input values.
+ *
+ *	project := builder.New()
+ *	score   := project.Variable("score", 0)
+ *	project.Sprite("Player").Script (
+ *		builder.WhenFlagClicked(),
+ *		builder.SetVariable(score, 0),
+ *		builder.Forever (
+ *			builder.If (
+ *				builder.TouchingObject("_edge_"),
+ *				builder.ChangeVariable(score, 1)),
+ *			builder.MoveSteps(10)))
+ *	err := project.Write(file)
+ */
+package builder
+
+import "io"
+import "fmt"
+import "strings"
+import "github.com/scapi3/sb3"
+
+// stageSVG is the blank white backdrop that is given to the stage if it has no
+// backdrops.
+const stageSVG = `<svg version="1.1" xmlns="http://www.w3.org/2000/svg" ` +
+	`width="480" height="360" viewBox="0 0 480 360">` +
+	`<rect width="480" height="360" fill="#ffffff"/></svg>`
+
+// spriteSVG is the empty costume that is given to sprites with no costumes.
+const spriteSVG = `<svg version="1.1" xmlns="http://www.w3.org/2000/svg" ` +
+	`width="2" height="2" viewBox="0 0 2 2"></svg>`
+
+// coreCategories holds the block categories that do not belong to an
+// extension.
+var coreCategories = map[string] bool {
+	"motion":     true,
+	"looks":      true,
+	"sound":      true,
+	"event":      true,
+	"control":    true,
+	"sensing":    true,
+	"operator":   true,
+	"data":       true,
+	"procedures": true,
+	"argument":   true,
+	"math":       true,
+	"text":       true,
+	"colour":     true,
+}
+
+/* Project is a Scratch 3 project that is being built. Errors that happen while
+ * building are remembered, and returned by Build and Write.
+ */
+type Project struct {
+	stage   *Sprite
+	sprites []*Sprite
+	assets  map[string] []byte
+	nextID  int
+	err     error
+}
+
+/* New creates an empty project that only has a stage.
+ */
+func New () (project *Project) {
+	project = &Project {
+		assets: map[string] []byte { },
+	}
+	project.stage = project.newSprite("Stage", true)
+	return
+}
+
+/* Stage returns the stage of the project.
+ */
+func (project *Project) Stage () (stage *Sprite) {
+	return project.stage
+}
+
+/* Sprite adds a new sprite to the project. If a sprite with the same name
+ * already exists, it is returned instead.
+ */
+func (project *Project) Sprite (name string) (sprite *Sprite) {
+	for _, sprite := range project.sprites {
+		if sprite.target.Name == name { return sprite }
+	}
+	sprite = project.newSprite(name, false)
+	sprite.target.LayerOrder = len(project.sprites) + 1
+	project.sprites = append(project.sprites, sprite)
+	return
+}
+
+/* Variable adds a global variable to the project, which is stored on the
+ * stage.
+ */
+func (project *Project) Variable (name string, value any) (variable *Variable) {
+	return project.stage.Variable(name, value)
+}
+
+/* CloudVariable adds a cloud variable to the project. The cloud symbol is put
+ * in front of the name if it is not already there.
+ */
+func (project *Project) CloudVariable (
+	name  string,
+	value any,
+) (
+	variable *Variable,
+) {
+	if !strings.HasPrefix(name, sb3.CloudSymbol) {
+		name = sb3.CloudSymbol + name
+	}
+	variable = project.stage.Variable(name, value)
+	project.stage.target.Variables[variable.ID].IsCloud = true
+	return
+}
+
+/* List adds a global list to the project, which is stored on the stage.
+ */
+func (project *Project) List (name string, values ...any) (list *List) {
+	return project.stage.List(name, values...)
+}
+
+/* Broadcast adds a broadcast message to the project. If a broadcast with the
+ * same name already exists, it is returned instead.
+ */
+func (project *Project) Broadcast (name string) (broadcast *Broadcast) {
+	broadcasts := project.stage.target.Broadcasts
+	for id, existing := range broadcasts {
+		if existing == name { return &Broadcast { ID: id, Name: name } }
+	}
+
+	broadcast = &Broadcast { ID: project.newID(), Name: name }
+	broadcasts[broadcast.ID] = name
+	return
+}
+
+/* Build finishes the project and returns it, along with the contents of its
+ * costumes and sounds keyed by file name. Targets without costumes are given a
+ * blank one, extensions used by blocks are added to the project, and the
+ * project is validated. The returned project is a copy, so the builder can be
+ * changed and built again afterwards.
+ */
+func (project *Project) Build () (
+	built  *sb3.Project,
+	assets map[string] []byte,
+	err    error,
+) {
+	if project.err != nil { return nil, nil, project.err }
+
+	built = &sb3.Project {
+		Targets: []*sb3.Target { copyTarget(project.stage.target) },
+		Meta: sb3.Meta {
+			Semver: "3.0.0",
+			VM:     "0.2.0",
+			Agent:  "scapi3",
+		},
+	}
+	for _, sprite := range project.sprites {
+		built.Targets = append(built.Targets, copyTarget(sprite.target))
+	}
+	assets = make(map[string] []byte, len(project.assets))
+	for name, data := range project.assets {
+		assets[name] = data
+	}
+
+	for _, target := range built.Targets {
+		if len(target.Costumes) > 0 { continue }
+		if target.IsStage {
+			addCostume (
+				target, assets,
+				"backdrop1", "svg", []byte(stageSVG), 240, 180)
+		} else {
+			addCostume (
+				target, assets,
+				"costume1", "svg", []byte(spriteSVG), 1, 1)
+		}
+	}
+	built.Extensions = extensions(built)
+
+	err = built.Validate()
+	if err != nil { return nil, nil, err }
+	return built, assets, nil
+}
+
+/* Write builds the project and writes it to writer as an sb3 file.
+ */
+func (project *Project) Write (writer io.Writer) (err error) {
+	built, assets, err := project.Build()
+	if err != nil { return }
+	return sb3.Write(writer, built, assets)
+}
+
+/* fail remembers the first error that happens while building.
+ */
+func (project *Project) fail (err error) {
+	if project.err == nil { project.err = err }
+}
+
+/* newID returns an ID that has not been used in the project yet.
+ */
+func (project *Project) newID () (id string) {
+	project.nextID ++
+	return fmt.Sprintf("id%d", project.nextID)
+}
+
+/* newSprite creates a target.
+ */
+func (project *Project) newSprite (name string, stage bool) (sprite *Sprite) {
+	target := &sb3.Target {
+		IsStage:    stage,
+		Name:       name,
+		Variables:  map[string] *sb3.Variable { },
+		Lists:      map[string] *sb3.List     { },
+		Broadcasts: map[string] string        { },
+		Blocks:     map[string] *sb3.Block    { },
+		Comments:   map[string] *sb3.Comment  { },
+		Volume:     100,
+	}
+	if stage {
+		target.Tempo             = 60
+		target.VideoTransparency = 50
+		target.VideoState        = "on"
+	} else {
+		target.Visible       = true
+		target.Size          = 100
+		target.Direction     = 90
+		target.Draggable     = false
+		target.RotationStyle = "all around"
+	}
+	return &Sprite { project: project, target: target }
+}
+
+/* copyTarget returns a deep copy of a target, so that changing the builder
+ * after a project is built does not change the built project, or the other
+ * way around.
+ */
+func copyTarget (target *sb3.Target) (copied *sb3.Target) {
+	copied = &sb3.Target { }
+	*copied = *target
+	copied.Variables  = copyItems(target.Variables, copyItem[sb3.Variable])
+	copied.Lists      = copyItems(target.Lists,     copyList)
+	copied.Broadcasts = copyMap(target.Broadcasts)
+	copied.Blocks     = copyItems(target.Blocks,    copyBlock)
+	copied.Comments   = copyItems(target.Comments,  copyItem[sb3.Comment])
+	copied.Costumes   = make([]*sb3.Costume, len(target.Costumes))
+	for index, costume := range target.Costumes {
+		copied.Costumes[index] = copyItem(costume)
+	}
+	copied.Sounds = make([]*sb3.Sound, len(target.Sounds))
+	for index, sound := range target.Sounds {
+		copied.Sounds[index] = copyItem(sound)
+	}
+	return
+}
+
+/* copyMap returns a shallow copy of a map.
+ */
+func copyMap [T any] (items map[string] T) (copied map[string] T) {
+	copied = make(map[string] T, len(items))
+	for key, item := range items {
+		copied[key] = item
+	}
+	return
+}
+
+/* copyItems returns a copy of a map, with each item copied by copyItem.
+ */
+func copyItems [T any] (
+	items    map[string] *T,
+	copyItem func (*T) *T,
+) (
+	copied map[string] *T,
+) {
+	copied = make(map[string] *T, len(items))
+	for key, item := range items {
+		copied[key] = copyItem(item)
+	}
+	return
+}
+
+/* copyItem returns a copy of an item that holds nothing which the builder
+ * changes in place. Nil items stay nil.
+ */
+func copyItem [T any] (item *T) (copied *T) {
+	if item == nil { return nil }
+	copied  = new(T)
+	*copied = *item
+	return
+}
+
+/* copyList returns a copy of a list and its contents.
+ */
+func copyList (list *sb3.List) (copied *sb3.List) {
+	copied = copyItem(list)
+	if copied != nil && copied.Values != nil {
+		copied.Values = append([]any { }, list.Values...)
+	}
+	return
+}
+
+/* copyBlock returns a copy of a block, including its inputs, fields,
+ * mutation, and primitive.
+ */
+func copyBlock (block *sb3.Block) (copied *sb3.Block) {
+	copied = copyItem(block)
+	if copied == nil { return }
+	copied.Inputs = make(map[string] sb3.Input, len(block.Inputs))
+	for name, input := range block.Inputs {
+		input.Block.Primitive  = copyItem(input.Block.Primitive)
+		input.Shadow.Primitive = copyItem(input.Shadow.Primitive)
+		copied.Inputs[name] = input
+	}
+	copied.Fields    = copyMap(block.Fields)
+	copied.Primitive = copyItem(block.Primitive)
+	copied.Mutation  = copyItem(block.Mutation)
+	if copied.Mutation != nil && copied.Mutation.Children != nil {
+		copied.Mutation.Children = append (
+			[]any { }, block.Mutation.Children...)
+	}
+	return
+}
+
+/* extensions returns the IDs of the extensions whose blocks are used in a
+ * project. The ID of an extension is the part of its opcodes before the first
+ * underscore.
+ */
+func extensions (project *sb3.Project) (ids []string) {
+	found := map[string] bool { }
+	for _, target := range project.Targets {
+		for _, block := range target.Blocks {
+			category, _, _ := strings.Cut(block.Opcode, "_")
+			if block.Primitive != nil || coreCategories[category] { continue }
+			found[category] = true
+		}
+	}
+	return sb3.SortedKeys(found)
+}
+
+/* Variable is a variable that has been added to a project.
+ */
+type Variable struct {
+	ID   string
+	Name string
+}
+
+/* List is a list that has been added to a project.
+ */
+type List struct {
+	ID   string
+	Name string
+}
+
+/* Broadcast is a broadcast message that has been added to a project.
+ */
+type Broadcast struct {
+	ID   string
+	Name string
+}
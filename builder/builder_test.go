@@ -0,0 +1,104 @@
+package builder
+
+import "bytes"
+import "testing"
+import "image"
+import "image/png"
+
+func TestBuildDoesNotChangeProject (test *testing.T) {
+	project := New()
+	sprite  := project.Sprite("Cat")
+	sprite.Script(WhenFlagClicked(), MoveSteps(10))
+
+	first, firstAssets, err := project.Build()
+	if err != nil { test.Fatal(err) }
+	if len(sprite.target.Costumes) != 0 || len(project.stage.target.Costumes) != 0 {
+		test.Fatal("building added default costumes to the builder")
+	}
+	if len(project.assets) != 0 {
+		test.Fatalf("building added assets to the builder: %v", project.assets)
+	}
+	if len(first.Target("Cat").Costumes) != 1 || len(firstAssets) != 2 {
+		test.Fatal("built project has no default costumes")
+	}
+
+	// changes after building must not show up in the built project
+	sprite.Script(WhenFlagClicked())
+	second, _, err := project.Build()
+	if err != nil { test.Fatal(err) }
+	if len(first.Target("Cat").Blocks) != 2 {
+		test.Fatalf("first build has %d blocks, expected 2", len(first.Target("Cat").Blocks))
+	}
+	if len(second.Target("Cat").Blocks) != 3 {
+		test.Fatalf("second build has %d blocks, expected 3", len(second.Target("Cat").Blocks))
+	}
+	if len(second.Target("Cat").Costumes) != 1 {
+		test.Fatalf("second build has %d costumes, expected 1", len(second.Target("Cat").Costumes))
+	}
+}
+
+func TestCostumeResolution (test *testing.T) {
+	buffer := bytes.Buffer { }
+	err := png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	if err != nil { test.Fatal(err) }
+
+	project := New()
+	project.Sprite("Cat").
+		Costume("vector", "svg", []byte(`<svg width="10" height="10"/>`)).
+		Costume("bitmap", "png", buffer.Bytes())
+	built, _, err := project.Build()
+	if err != nil { test.Fatal(err) }
+
+	sprite := built.Target("Cat")
+	vector := sprite.Costume("vector")
+	if vector.BitmapResolution != 1 {
+		test.Fatalf("svg costume has resolution %d, expected 1", vector.BitmapResolution)
+	}
+	bitmap := sprite.Costume("bitmap")
+	if bitmap.BitmapResolution != 2 {
+		test.Fatalf("png costume has resolution %d, expected 2", bitmap.BitmapResolution)
+	}
+	if bitmap.RotationCenterX != 20 || bitmap.RotationCenterY != 10 {
+		test.Fatalf (
+			"png costume is centered at %v, %v, expected 20, 10",
+			bitmap.RotationCenterX, bitmap.RotationCenterY)
+	}
+}
+
+func TestBuildCopiesItems (test *testing.T) {
+	project := New()
+	sprite  := project.Sprite("Cat")
+	sprite.Variable("score", 0)
+	sprite.List("items", "a")
+	sprite.Script(WhenFlagClicked(), MoveSteps(10))
+	built, _, err := project.Build()
+	if err != nil { test.Fatal(err) }
+
+	// change every item in the builder, and check the built project
+	for _, variable := range sprite.target.Variables { variable.Value = 1 }
+	for _, list := range sprite.target.Lists { list.Values[0] = "b" }
+	for _, block := range sprite.target.Blocks {
+		block.Next = "changed"
+		for name, input := range block.Inputs {
+			if input.Block.Primitive != nil { input.Block.Primitive.Value = 20 }
+			block.Inputs[name] = input
+		}
+	}
+
+	target := built.Target("Cat")
+	for _, variable := range target.Variables {
+		if variable.Value != 0 { test.Fatalf("variable was changed to %v", variable.Value) }
+	}
+	for _, list := range target.Lists {
+		if list.Values[0] != "a" { test.Fatalf("list was changed to %v", list.Values) }
+	}
+	for id, block := range target.Blocks {
+		if block.Next == "changed" { test.Fatalf("block %s was changed", id) }
+		for name, input := range block.Inputs {
+			primitive := input.Block.Primitive
+			if primitive != nil && primitive.Value == 20 {
+				test.Fatalf("input %s of block %s was changed", name, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package builder
+
+import "fmt"
+import "encoding/json"
+import "github.com/scapi3/sb3"
+
+/* Procedure is a custom block that has been added to a sprite.
+ */
+type Procedure struct {
+	sprite   *Sprite
+	procCode string
+	names    []string
+	ids      []string
+	kinds    []byte
+	warp     bool
+}
+
+/* Procedure adds a custom block to the sprite. ProcCode is the text of the
+ * block, with "%s" in place of each text or number argument and "%b" in place
+ * of each boolean argument, and names holds the names of the arguments in
+ * order. The block is defined by calling Define.
+ */
+func (sprite *Sprite) Procedure (
+	procCode string,
+	names    ...string,
+) (
+	procedure *Procedure,
+) {
+	procedure = &Procedure {
+		sprite:   sprite,
+		procCode: procCode,
+		names:    names,
+	}
+	for index := 0; index + 1 < len(procCode); index ++ {
+		if procCode[index] != '%' { continue }
+		kind := procCode[index + 1]
+		if kind != 's' && kind != 'b' { continue }
+		procedure.kinds = append(procedure.kinds, kind)
+		procedure.ids   = append(procedure.ids, sprite.project.newID())
+		index ++
+	}
+
+	if len(procedure.kinds) != len(names) {
+		sprite.project.fail(fmt.Errorf (
+			"custom block %q has %d arguments but %d names",
+			procCode, len(procedure.kinds), len(names)))
+	}
+	return
+}
+
+/* Warp makes the custom block run without screen refresh. It must be called
+ * before Define and Call.
+ */
+func (procedure *Procedure) Warp () (self *Procedure) {
+	procedure.warp = true
+	return procedure
+}
+
+/* Define adds the definition of the custom block to the sprite, followed by
+ * the specified blocks.
+ */
+func (procedure *Procedure) Define (blocks ...*Block) (self *Procedure) {
+	prototype := NewBlock("procedures_prototype")
+	prototype.shadow = true
+	prototype.mutation = procedure.mutation(true)
+	for index, id := range procedure.ids {
+		reporter := procedure.argument(index)
+		reporter.shadow = true
+		prototype.setInput(blockInput {
+			name:  id,
+			kind:  inputShadow,
+			value: reporter,
+		})
+	}
+
+	definition := NewBlock("procedures_definition")
+	definition.setInput(blockInput {
+		name:  "custom_block",
+		kind:  inputShadow,
+		value: prototype,
+	})
+	procedure.sprite.Script(append([]*Block { definition }, blocks...)...)
+	return procedure
+}
+
+/* Call creates a block that calls the custom block. There must be one value
+ * for each argument. Values for boolean arguments must be blocks or nil.
+ */
+func (procedure *Procedure) Call (values ...any) (block *Block) {
+	block = NewBlock("procedures_call").Mutation(procedure.mutation(false))
+	if len(values) != len(procedure.ids) {
+		procedure.sprite.project.fail(fmt.Errorf (
+			"custom block %q takes %d arguments but was called with %d",
+			procedure.procCode, len(procedure.ids), len(values)))
+		return
+	}
+
+	for index, id := range procedure.ids {
+		if procedure.kinds[index] == 's' {
+			block.Input(id, text, values[index])
+			continue
+		}
+		
+		condition, ok := values[index].(*Block)
+		if !ok && values[index] != nil {
+			procedure.sprite.project.fail(fmt.Errorf (
+				"argument %d of custom block %q must be a block",
+				index + 1, procedure.procCode))
+		}
+		block.Condition(id, condition)
+	}
+	return
+}
+
+/* Argument creates a reporter for one of the custom block's arguments, to be
+ * used inside of its definition.
+ */
+func (procedure *Procedure) Argument (name string) (block *Block) {
+	for index, argument := range procedure.names {
+		if argument == name { return procedure.argument(index) }
+	}
+	procedure.sprite.project.fail(fmt.Errorf (
+		"custom block %q has no argument %q",
+		procedure.procCode, name))
+	return NewBlock("argument_reporter_string_number").Field("VALUE", name)
+}
+
+/* argument creates a reporter for the argument at the specified index.
+ */
+func (procedure *Procedure) argument (index int) (block *Block) {
+	opcode := "argument_reporter_string_number"
+	if procedure.kinds[index] == 'b' { opcode = "argument_reporter_boolean" }
+	
+	name := ""
+	if index < len(procedure.names) { name = procedure.names[index] }
+	return NewBlock(opcode).Field("VALUE", name)
+}
+
+/* mutation creates the mutation for the custom block's prototype, or for a
+ * call to it.
+ */
+func (procedure *Procedure) mutation (prototype bool) (mutation *sb3.Mutation) {
+	mutation = &sb3.Mutation {
+		TagName:     "mutation",
+		ProcCode:    procedure.procCode,
+		ArgumentIDs: encodeStringList(procedure.ids),
+		Warp:        fmt.Sprint(procedure.warp),
+	}
+	if !prototype { return }
+
+	defaults := make([]string, len(procedure.kinds))
+	for index, kind := range procedure.kinds {
+		if kind == 'b' { defaults[index] = "false" }
+	}
+	names := procedure.names
+	if len(names) > len(procedure.ids) { names = names[:len(procedure.ids)] }
+	mutation.ArgumentNames    = encodeStringList(names)
+	mutation.ArgumentDefaults = encodeStringList(defaults)
+	return
+}
+
+/* encodeStringList encodes a list of strings as JSON, which is how Scratch
+ * stores lists inside of mutations.
+ */
+func encodeStringList (list []string) (encoded string) {
+	if list == nil { list = []string { } }
+	data, _ := json.Marshal(list)
+	return string(data)
+}
@@ -0,0 +1,398 @@
+package builder
+
+import "fmt"
+import "bytes"
+import "image"
+import "strconv"
+import "strings"
+import "encoding/xml"
+import "github.com/scapi3/sb3"
+import _ "image/png"
+import _ "image/jpeg"
+
+// scriptSpacing is the vertical space that is left between scripts, and
+// blockHeight is roughly how tall a block is in the code area.
+const (
+	scriptSpacing = 64
+	blockHeight   = 48
+)
+
+/* Sprite is a sprite, or the stage, that is being built.
+ */
+type Sprite struct {
+	project *Project
+	target  *sb3.Target
+
+	// nextY is where the next script will be placed in the code area.
+	nextY float64
+}
+
+/* Target returns the target that is being built. It can be used to change
+ * properties that the builder does not have methods for.
+ */
+func (sprite *Sprite) Target () (target *sb3.Target) {
+	return sprite.target
+}
+
+/* Position sets where the sprite is on the stage.
+ */
+func (sprite *Sprite) Position (x, y float64) (self *Sprite) {
+	sprite.target.X = x
+	sprite.target.Y = y
+	return sprite
+}
+
+/* Variable adds a variable to the sprite. Variables added to the stage are
+ * global. If a variable with the same name already exists, it is returned
+ * instead.
+ */
+func (sprite *Sprite) Variable (name string, value any) (variable *Variable) {
+	for id, existing := range sprite.target.Variables {
+		if existing.Name == name { return &Variable { ID: id, Name: name } }
+	}
+
+	variable = &Variable { ID: sprite.project.newID(), Name: name }
+	sprite.target.Variables[variable.ID] = &sb3.Variable {
+		ID:    variable.ID,
+		Name:  name,
+		Value: value,
+	}
+	return
+}
+
+/* List adds a list to the sprite. Lists added to the stage are global. If a
+ * list with the same name already exists, it is returned instead.
+ */
+func (sprite *Sprite) List (name string, values ...any) (list *List) {
+	for id, existing := range sprite.target.Lists {
+		if existing.Name == name { return &List { ID: id, Name: name } }
+	}
+
+	if values == nil { values = []any { } }
+	list = &List { ID: sprite.project.newID(), Name: name }
+	sprite.target.Lists[list.ID] = &sb3.List {
+		ID:     list.ID,
+		Name:   name,
+		Values: values,
+	}
+	return
+}
+
+/* Costume adds a costume to the sprite, or a backdrop to the stage. Format is
+ * the file extension of the image, such as "svg" or "png". The rotation center
+ * is placed in the middle of the image if its size can be read.
+ */
+func (sprite *Sprite) Costume (
+	name   string,
+	format string,
+	data   []byte,
+) (
+	self *Sprite,
+) {
+	width, height := imageSize(format, data)
+	addCostume (
+		sprite.target, sprite.project.assets,
+		name, format, data, width / 2, height / 2)
+	return sprite
+}
+
+/* Sound adds a sound to the sprite. Format is the file extension of the audio,
+ * such as "wav" or "mp3".
+ */
+func (sprite *Sprite) Sound (
+	name        string,
+	format      string,
+	data        []byte,
+	rate        int,
+	sampleCount int,
+) (
+	self *Sprite,
+) {
+	fileName := sb3.AssetName(data, format)
+	sprite.project.assets[fileName] = data
+	sprite.target.Sounds = append(sprite.target.Sounds, &sb3.Sound {
+		AssetID:     sb3.AssetID(data),
+		Name:        name,
+		DataFormat:  format,
+		Rate:        rate,
+		SampleCount: sampleCount,
+		MD5Ext:      fileName,
+	})
+	return sprite
+}
+
+/* Script adds a script to the sprite. The blocks are stacked in order, and the
+ * first one is usually a hat block.
+ */
+func (sprite *Sprite) Script (blocks ...*Block) (self *Sprite) {
+	if len(blocks) == 0 { return sprite }
+
+	before := len(sprite.target.Blocks)
+	id := sprite.emitStack(blocks, "")
+	first := sprite.target.Blocks[id]
+	first.TopLevel = true
+	first.X = 0
+	first.Y = sprite.nextY
+
+	emitted := len(sprite.target.Blocks) - before
+	sprite.nextY += float64(emitted * blockHeight + scriptSpacing)
+	return sprite
+}
+
+/* addCostume adds a costume with a known rotation center to a target, and
+ * stores its image in assets. Scratch draws bitmap costumes at half of their
+ * size, so that they look sharp on high resolution screens, and the costume's
+ * bitmap resolution is set to match.
+ */
+func addCostume (
+	target  *sb3.Target,
+	assets  map[string] []byte,
+	name    string,
+	format  string,
+	data    []byte,
+	centerX float64,
+	centerY float64,
+) {
+	resolution := 1
+	if format != "svg" { resolution = 2 }
+
+	fileName := sb3.AssetName(data, format)
+	assets[fileName] = data
+	target.Costumes = append(target.Costumes, &sb3.Costume {
+		AssetID:          sb3.AssetID(data),
+		Name:             name,
+		BitmapResolution: resolution,
+		MD5Ext:           fileName,
+		DataFormat:       format,
+		RotationCenterX:  centerX,
+		RotationCenterY:  centerY,
+	})
+}
+
+/* emitStack adds a stack of blocks to the sprite and returns the ID of the
+ * first one. Parent is the ID of the C block that the stack is inside of, or
+ * blank for scripts.
+ */
+func (sprite *Sprite) emitStack (blocks []*Block, parent string) (first string) {
+	var previous *sb3.Block
+	for _, block := range blocks {
+		id := sprite.emit(block, parent)
+		if previous == nil {
+			first = id
+		} else {
+			previous.Next = id
+		}
+		previous = sprite.target.Blocks[id]
+		parent   = id
+	}
+	return
+}
+
+/* emit adds a block to the sprite along with the blocks in its inputs, and
+ * returns its ID.
+ */
+func (sprite *Sprite) emit (block *Block, parent string) (id string) {
+	id = sprite.project.newID()
+	emitted := &sb3.Block {
+		ID:       id,
+		Opcode:   block.opcode,
+		Parent:   parent,
+		Inputs:   map[string] sb3.Input { },
+		Fields:   map[string] sb3.Field { },
+		Shadow:   block.shadow,
+		Mutation: block.mutation,
+	}
+	sprite.target.Blocks[id] = emitted
+
+	for _, input := range block.inputs {
+		switch input.kind {
+		case inputValue:
+			emitted.Inputs[input.name] = sprite.emitValue (
+				input.value, input.primitive, id)
+		case inputMenu:
+			emitted.Inputs[input.name] = sprite.emitMenu (
+				input.value, input.menuOpcode, input.menuField, id)
+		case inputCondition:
+			condition, ok := input.value.(*Block)
+			if !ok || condition == nil { continue }
+			emitted.Inputs[input.name] = sb3.Input {
+				ShadowType: sb3.ShadowTypeNone,
+				Block:      sb3.InputValue { BlockID: sprite.emit(condition, id) },
+			}
+		case inputSubstack:
+			if len(input.blocks) == 0 { continue }
+			emitted.Inputs[input.name] = sb3.Input {
+				ShadowType: sb3.ShadowTypeNone,
+				Block: sb3.InputValue {
+					BlockID: sprite.emitStack(input.blocks, id),
+				},
+			}
+		case inputShadow:
+			shadow := input.value.(*Block)
+			emitted.Inputs[input.name] = sb3.Input {
+				ShadowType: sb3.ShadowTypeSame,
+				Block:      sb3.InputValue { BlockID: sprite.emit(shadow, id) },
+			}
+		}
+	}
+
+	for name, value := range block.fields {
+		emitted.Fields[name] = field(value)
+	}
+	return
+}
+
+/* emitValue creates an input that holds a value. Literal values are stored in
+ * a shadow of the specified type. Blocks, variables, and lists cover up an
+ * empty shadow of that type.
+ */
+func (sprite *Sprite) emitValue (
+	value     any,
+	primitive sb3.PrimitiveType,
+	parent    string,
+) (
+	input sb3.Input,
+) {
+	shadow := sb3.InputValue {
+		Primitive: &sb3.Primitive { Type: primitive, Value: "" },
+	}
+	obscure := func (block sb3.InputValue) sb3.Input {
+		if primitive == sb3.PrimitiveTypeBroadcast {
+			// broadcast shadows must refer to a broadcast that
+			// exists, so use the one Scratch puts in new projects
+			broadcast := sprite.project.Broadcast("message1")
+			shadow.Primitive.Name = broadcast.Name
+			shadow.Primitive.ID   = broadcast.ID
+		}
+		return sb3.Input {
+			ShadowType: sb3.ShadowTypeObscured,
+			Block:      block,
+			Shadow:     shadow,
+		}
+	}
+
+	switch value := value.(type) {
+	case *Block:
+		return obscure(sb3.InputValue { BlockID: sprite.emit(value, parent) })
+	case *Variable:
+		return obscure(sb3.InputValue { Primitive: &sb3.Primitive {
+			Type: sb3.PrimitiveTypeVariable,
+			Name: value.Name,
+			ID:   value.ID,
+		} })
+	case *List:
+		return obscure(sb3.InputValue { Primitive: &sb3.Primitive {
+			Type: sb3.PrimitiveTypeList,
+			Name: value.Name,
+			ID:   value.ID,
+		} })
+	case *Broadcast:
+		return sb3.Input {
+			ShadowType: sb3.ShadowTypeSame,
+			Block: sb3.InputValue { Primitive: &sb3.Primitive {
+				Type: sb3.PrimitiveTypeBroadcast,
+				Name: value.Name,
+				ID:   value.ID,
+			} },
+		}
+	case nil:
+		return sb3.Input { ShadowType: sb3.ShadowTypeSame, Block: shadow }
+	default:
+		return sb3.Input {
+			ShadowType: sb3.ShadowTypeSame,
+			Block: sb3.InputValue { Primitive: &sb3.Primitive {
+				Type:  primitive,
+				Value: literal(value),
+			} },
+		}
+	}
+}
+
+/* emitMenu creates an input that holds a menu. Literal values are selected in
+ * a shadow menu block, and blocks cover up a menu with nothing selected.
+ */
+func (sprite *Sprite) emitMenu (
+	value  any,
+	opcode string,
+	name   string,
+	parent string,
+) (
+	input sb3.Input,
+) {
+	menu := func (selected any) string {
+		return sprite.emit(&Block {
+			opcode: opcode,
+			shadow: true,
+			fields: map[string] any { name: selected },
+		}, parent)
+	}
+
+	if block, ok := value.(*Block); ok {
+		return sb3.Input {
+			ShadowType: sb3.ShadowTypeObscured,
+			Block:      sb3.InputValue { BlockID: sprite.emit(block, parent) },
+			Shadow:     sb3.InputValue { BlockID: menu("") },
+		}
+	}
+	return sb3.Input {
+		ShadowType: sb3.ShadowTypeSame,
+		Block:      sb3.InputValue { BlockID: menu(literal(value)) },
+	}
+}
+
+/* field creates a field from a value. Variables, lists, and broadcasts are
+ * stored along with their IDs.
+ */
+func field (value any) (created sb3.Field) {
+	switch value := value.(type) {
+	case *Variable:  return sb3.Field { Value: value.Name, ID: value.ID }
+	case *List:      return sb3.Field { Value: value.Name, ID: value.ID }
+	case *Broadcast: return sb3.Field { Value: value.Name, ID: value.ID }
+	default:         return sb3.Field { Value: literal(value) }
+	}
+}
+
+/* literal converts a Go value into one that can be stored in a project.
+ * Strings, numbers, and booleans are stored as they are, and anything else is
+ * formatted as a string.
+ */
+func literal (value any) (converted any) {
+	switch value.(type) {
+	case
+		string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+/* imageSize returns the size of a PNG, JPEG, or SVG image, or zero if it
+ * cannot be read.
+ */
+func imageSize (format string, data []byte) (width, height float64) {
+	if format != "svg" {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil { return }
+		return float64(config.Width), float64(config.Height)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil { return }
+		element, ok := token.(xml.StartElement)
+		if !ok { continue }
+
+		for _, attribute := range element.Attr {
+			value := strings.TrimSuffix(attribute.Value, "px")
+			switch attribute.Name.Local {
+			case "width":  width,  _ = strconv.ParseFloat(value, 64)
+			case "height": height, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+		return
+	}
+}
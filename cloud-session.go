@@ -1,29 +1,42 @@
 package scapi3
 
 import "fmt"
+import "bytes"
 import "strings"
 import "strconv"
-import "net/url"
 import "net/http"
+import "encoding/json"
+import "github.com/scapi3/sb3"
 import "github.com/gorilla/websocket"
 
-/* CloudMethod represents the method of a cloud session message. It can be
- * either handshake, or set. Handshake initialtes a cloud connection, and set
- * sets a variable.
+// CloudURL is the address of the cloud server. It can be changed to connect
+// cloud sessions to a stand-in server, such as in tests.
+var CloudURL = "wss://clouddata.scratch.mit.edu/"
+
+/* CloudMethod represents the method of a cloud session message. Handshake
+ * initiates a cloud connection, set sets a variable, create creates a new
+ * variable, delete deletes a variable, and rename changes the name of a
+ * variable.
  */
 type CloudMethod string
 
 const (
 	CloudMethodHandshake CloudMethod = "handshake"
 	CloudMethodSet       CloudMethod = "set"
+	CloudMethodCreate    CloudMethod = "create"
+	CloudMethodDelete    CloudMethod = "delete"
+	CloudMethodRename    CloudMethod = "rename"
 )
 
-/* CloudMessage represents a single message sent over a cloud session.
+/* CloudMessage represents a single message sent over a cloud session. NewName
+ * is only used by rename messages. Variable points to the variable the message
+ * affected, and is filled in by ReadMessage.
  */
 type CloudMessage struct {
 	Method   CloudMethod `json:"method"`
 	Name     string      `json:"name"`
 	Value    string      `json:"value"`
+	NewName  string      `json:"new_name"`
 	Variable *CloudVariable
 }
 
@@ -45,6 +58,10 @@ type CloudSession struct {
 	projectID   uint64
 	connection  *websocket.Conn
 	variables   map[string] *CloudVariable
+
+	// the server may send several messages in a single websocket frame,
+	// so messages that have not been returned yet are kept here.
+	pending []CloudMessage
 }
 
 /* CreateCloudSession creates a new cloud session for the specified user in the
@@ -56,62 +73,247 @@ func CreateCloudSession (
 ) (
 	session *CloudSession,
 	err error,
+) {
+	return createCloudSession(userSession, projectID, false)
+}
+
+/* CreateCloudSessionWithVariables creates a new cloud session for the specified
+ * user in the specified project, like CreateCloudSession. Before connecting,
+ * the project is downloaded and the session's variables are filled in with the
+ * cloud variables it declares, so they can be accessed even if the server does
+ * not send them.
+ */
+func CreateCloudSessionWithVariables (
+	userSession *UserSession,
+	projectID   uint64,
+) (
+	session *CloudSession,
+	err error,
+) {
+	return createCloudSession(userSession, projectID, true)
+}
+
+/* createCloudSession connects to the cloud server, optionally loading the
+ * project's variables first.
+ */
+func createCloudSession (
+	userSession *UserSession,
+	projectID   uint64,
+	populate    bool,
+) (
+	session *CloudSession,
+	err error,
 ) {
 	session = &CloudSession {
 		userSession: userSession,
 		projectID:   projectID,
+		variables:   make(map[string] *CloudVariable),
+	}
+
+	if populate {
+		err = session.LoadVariables()
+		if err != nil { return }
 	}
 
 	header := http.Header { }
-	header.Add("Cookie", userSession.sessionID)
 	header.Set("User-Agent", "")
 	header.Set("Origin", "https://scratch.mit.edu")
 
-	cloudUrl := url.URL {
-		Scheme: "ws",
-		Host:   "clouddata.scratch.mit.edu",
-		Path:   "/",
-	}
-
-	session.connection, _, err = websocket.DefaultDialer.Dial (
-		cloudUrl.String(),
-		header)
-	if err != nil { return}
+	// the session cookie is set for all subdomains of scratch.mit.edu, so
+	// it is sent to the cloud server as well.
+	dialer := *websocket.DefaultDialer
+	if userSession.jar != nil { dialer.Jar = userSession.jar }
+	session.connection, _, err = dialer.Dial(CloudURL, header)
+	if err != nil { return }
 
 	err = session.sendHandshake()
 	return
 }
 
 /* ReadMessage reads a single message from the Scratch site, and returns it.
+ * The session's variables are updated according to the message before it is
+ * returned.
  */
 func (session *CloudSession) ReadMessage () (message CloudMessage, err error) {
-	err = session.connection.ReadJSON(message)
-	if err != nil { return }
+	for len(session.pending) == 0 {
+		var data []byte
+		_, data, err = session.connection.ReadMessage()
+		if err != nil { return }
+		
+		session.pending, err = parseCloudMessages(data)
+		if err != nil { return }
+	}
+
+	message = session.pending[0]
+	session.pending = session.pending[1:]
 
 	switch message.Method {
-	case CloudMethodSet:
+	case CloudMethodSet, CloudMethodCreate:
 		variable := session.GetVariable(message.Name)
 		if variable == nil {
-			variable = &CloudVariable { }
-			variable.SetString(message.Value)
-			session.variables[message.Name] = variable
+			variable = session.addVariable(message.Name)
 		}
-
+		variable.SetString(message.Value)
 		message.Variable = variable
-		break
+
+	case CloudMethodDelete:
+		message.Variable = session.removeVariable(message.Name)
+
+	case CloudMethodRename:
+		message.Variable = session.renameVariable (
+			message.Name, message.NewName)
 	}
 	
 	return
 }
 
+/* SetVariable sets the value of a cloud variable, creating it locally if it
+ * does not already exist.
+ */
+func (session *CloudSession) SetVariable (name, value string) (err error) {
+	name = session.normalizeName(name)
+	err = session.send (CloudMethodSet, map[string] any {
+		"name":  name,
+		"value": value,
+	})
+	if err != nil { return }
+
+	variable := session.GetVariable(name)
+	if variable == nil {
+		variable = session.addVariable(name)
+	}
+	variable.SetString(value)
+	return
+}
+
+/* CreateVariable creates a new cloud variable with the specified initial
+ * value.
+ */
+func (session *CloudSession) CreateVariable (name, value string) (err error) {
+	name = session.normalizeName(name)
+	err = session.send (CloudMethodCreate, map[string] any {
+		"name":  name,
+		"value": value,
+	})
+	if err != nil { return }
+
+	variable := session.GetVariable(name)
+	if variable == nil {
+		variable = session.addVariable(name)
+	}
+	variable.SetString(value)
+	return
+}
+
+/* DeleteVariable deletes a cloud variable.
+ */
+func (session *CloudSession) DeleteVariable (name string) (err error) {
+	name = session.normalizeName(name)
+	err = session.send (CloudMethodDelete, map[string] any {
+		"name": name,
+	})
+	if err != nil { return }
+	
+	session.removeVariable(name)
+	return
+}
+
+/* RenameVariable changes the name of a cloud variable.
+ */
+func (session *CloudSession) RenameVariable (name, newName string) (err error) {
+	name    = session.normalizeName(name)
+	newName = session.normalizeName(newName)
+	err = session.send (CloudMethodRename, map[string] any {
+		"name":     name,
+		"new_name": newName,
+	})
+	if err != nil { return }
+	
+	session.renameVariable(name, newName)
+	return
+}
+
+/* LoadVariables downloads the session's project, and adds each cloud variable
+ * it declares to the session with its initial value. Variables that the session
+ * already has are left alone.
+ */
+func (session *CloudSession) LoadVariables () (err error) {
+	variables, err := session.userSession.GetProjectCloudVariables (
+		session.projectID)
+	if err != nil { return }
+
+	for _, variable := range variables {
+		if session.GetVariable(variable.name) != nil { continue }
+		session.variables[variable.name] = variable
+	}
+	return
+}
+
+/* Variables returns all of the session's variables, keyed by name.
+ */
+func (session *CloudSession) Variables () (variables map[string] *CloudVariable) {
+	variables = make(map[string] *CloudVariable, len(session.variables))
+	for name, variable := range session.variables {
+		variables[name] = variable
+	}
+	return
+}
+
 /* GetVariable returns the cloud variable object of name string from the cloud
  * session.
  */
 func (session *CloudSession) GetVariable (name string) (variable *CloudVariable) {
+	return session.variables[session.normalizeName(name)]
+}
+
+/* normalizeName adds the cloud symbol to a variable name if it does not
+ * already have one.
+ */
+func (session *CloudSession) normalizeName (name string) (normalized string) {
 	if !strings.HasPrefix(name, "☁ ") {
 		name = addCloudSymbol(name)
 	}
-	return session.variables[name]
+	return name
+}
+
+/* addVariable adds a new, empty variable to the session's variable map.
+ */
+func (session *CloudSession) addVariable (name string) (variable *CloudVariable) {
+	name = session.normalizeName(name)
+	variable = &CloudVariable { name: name }
+	session.variables[name] = variable
+	return
+}
+
+/* removeVariable removes a variable from the session's variable map, and
+ * returns it. If the variable does not exist, nil is returned.
+ */
+func (session *CloudSession) removeVariable (name string) (variable *CloudVariable) {
+	name = session.normalizeName(name)
+	variable = session.variables[name]
+	delete(session.variables, name)
+	return
+}
+
+/* renameVariable moves a variable to a new name in the session's variable map,
+ * and returns it. If the variable does not exist, a new empty one is created
+ * under the new name.
+ */
+func (session *CloudSession) renameVariable (
+	name    string,
+	newName string,
+) (
+	variable *CloudVariable,
+) {
+	variable = session.removeVariable(name)
+	if variable == nil {
+		return session.addVariable(newName)
+	}
+	
+	newName = session.normalizeName(newName)
+	variable.name = newName
+	session.variables[newName] = variable
+	return
 }
 
 /* sendHandshake sends login information to the Scratch servers, officially
@@ -137,9 +339,12 @@ func (session *CloudSession) send (
 	data["user"]       = session.userSession.username
 	data["project_id"] = session.projectID
 
-	fmt.Println(data)
+	// each message must be terminated by a newline
+	encoded, err := json.Marshal(data)
+	if err != nil { return }
+	encoded = append(encoded, '\n')
 	
-	return session.connection.WriteJSON(data)
+	return session.connection.WriteMessage(websocket.TextMessage, encoded)
 }
 
 /* Close cleanly ends the cloud session.
@@ -152,6 +357,12 @@ func (session *CloudSession) Close () (err error) {
 	return
 }
 
+/* Name returns the name of the variable, including the cloud symbol.
+ */
+func (variable *CloudVariable) Name () (name string) {
+	return variable.name
+}
+
 /* SetString sets the variable value using a string. The float value is parsed
  * from this string.
  */
@@ -188,3 +399,85 @@ func (variable *CloudVariable) Float () (value float64) {
 func addCloudSymbol (variableName string) (cloudVariableName string) {
 	return "☁ " + variableName
 }
+
+/* parseCloudMessages parses the contents of a websocket frame sent by the
+ * cloud server, which may contain several newline separated messages. Values
+ * may be sent as either strings or numbers, so they are converted to strings.
+ */
+func parseCloudMessages (data []byte) (messages []CloudMessage, err error) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 { continue }
+
+		raw := struct {
+			Method  CloudMethod     `json:"method"`
+			Name    string          `json:"name"`
+			Value   json.RawMessage `json:"value"`
+			NewName string          `json:"new_name"`
+		} { }
+		err = json.Unmarshal(line, &raw)
+		if err != nil {
+			return nil, fmt.Errorf (
+				"cannot parse cloud message %q: %v",
+				string(line), err)
+		}
+
+		message := CloudMessage {
+			Method:  raw.Method,
+			Name:    raw.Name,
+			NewName: raw.NewName,
+		}
+		if len(raw.Value) > 0 {
+			var value string
+			if json.Unmarshal(raw.Value, &value) == nil {
+				message.Value = value
+			} else {
+				message.Value = string(raw.Value)
+			}
+		}
+		messages = append(messages, message)
+	}
+	return
+}
+
+/* GetProjectCloudVariables downloads a shared project and returns the cloud
+ * variables it declares, along with their initial values.
+ */
+func GetProjectCloudVariables (
+	projectID uint64,
+) (
+	variables []*CloudVariable,
+	err error,
+) {
+	return anonymous.GetProjectCloudVariables(projectID)
+}
+
+/* GetProjectCloudVariables downloads a project and returns the cloud variables
+ * it declares, along with their initial values. Unshared projects owned by the
+ * session's user can be used as well.
+ */
+func (session *UserSession) GetProjectCloudVariables (
+	projectID uint64,
+) (
+	variables []*CloudVariable,
+	err error,
+) {
+	data, projectJSON, err := session.GetProjectJSON(projectID)
+	if err != nil { return }
+	if projectJSON.Format != ProjectFormatSB3 {
+		return nil, fmt.Errorf (
+			"cannot get cloud variables of project %d: " +
+			"project is in %v format",
+			projectID, projectJSON.Format)
+	}
+
+	project, err := sb3.Parse(data)
+	if err != nil { return }
+
+	for _, definition := range project.CloudVariables() {
+		variable := &CloudVariable { name: definition.Name }
+		variable.SetString(fmt.Sprint(definition.Value))
+		variables = append(variables, variable)
+	}
+	return
+}
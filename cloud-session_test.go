@@ -0,0 +1,313 @@
+package scapi3
+
+import "time"
+import "strings"
+import "testing"
+import "net/http"
+import "encoding/json"
+import "net/http/httptest"
+import "github.com/gorilla/websocket"
+
+/* cloudServer is a stand-in for the cloud server. Messages sent by the client
+ * are decoded and passed to received, and frames written to send are passed on
+ * to the client as they are.
+ */
+type cloudServer struct {
+	server   *httptest.Server
+	received chan map[string] any
+	send     chan string
+}
+
+/* newCloudServer starts a stand-in cloud server, and points CloudURL at it
+ * until the test ends.
+ */
+func newCloudServer (test *testing.T) (server *cloudServer) {
+	server = &cloudServer {
+		received: make(chan map[string] any, 16),
+		send:     make(chan string, 16),
+	}
+
+	// the client claims to come from the Scratch website
+	upgrader := websocket.Upgrader {
+		CheckOrigin: func (request *http.Request) bool {
+			return request.Header.Get("Origin") == "https://scratch.mit.edu"
+		},
+	}
+	server.server = httptest.NewServer(http.HandlerFunc (
+		func (writer http.ResponseWriter, request *http.Request) {
+			connection, err := upgrader.Upgrade(writer, request, nil)
+			if err != nil { return }
+			defer connection.Close()
+
+			go func () {
+				for frame := range server.send {
+					connection.WriteMessage (
+						websocket.TextMessage,
+						[]byte(frame))
+				}
+			} ()
+
+			for {
+				_, data, err := connection.ReadMessage()
+				if err != nil { return }
+				if !strings.HasSuffix(string(data), "\n") {
+					test.Errorf("message %q has no newline", data)
+				}
+				message := map[string] any { }
+				err = json.Unmarshal(data, &message)
+				if err != nil {
+					test.Errorf("cannot parse message %q: %v", data, err)
+				}
+				server.received <- message
+			}
+		}))
+
+	previous := CloudURL
+	CloudURL = "ws" + strings.TrimPrefix(server.server.URL, "http")
+	test.Cleanup(func () {
+		CloudURL = previous
+		close(server.send)
+		server.server.Close()
+	})
+	return
+}
+
+/* connect creates a cloud session connected to the stand-in server, and checks
+ * the handshake it sends.
+ */
+func (server *cloudServer) connect (test *testing.T) (session *CloudSession) {
+	session, err := CreateCloudSession(&UserSession { username: "tester" }, 42)
+	if err != nil { test.Fatal(err) }
+	test.Cleanup(func () { session.Close() })
+
+	handshake := server.next(test)
+	if handshake["method"] != "handshake" {
+		test.Fatalf("first message is %v, expected handshake", handshake)
+	}
+	if handshake["user"] != "tester" || handshake["project_id"] != 42.0 {
+		test.Fatalf("handshake is %v", handshake)
+	}
+	return
+}
+
+/* next returns the next message that the server received.
+ */
+func (server *cloudServer) next (test *testing.T) (message map[string] any) {
+	test.Helper()
+	select {
+	case message = <- server.received:
+		return
+	case <- time.After(5 * time.Second):
+		test.Fatal("server did not receive a message")
+		return
+	}
+}
+
+/* expectVariables checks that the session has exactly the specified variables,
+ * given as names and values.
+ */
+func expectVariables (
+	test     *testing.T,
+	session  *CloudSession,
+	expected map[string] string,
+) {
+	test.Helper()
+	variables := session.Variables()
+	if len(variables) != len(expected) {
+		test.Fatalf("session has %d variables, expected %d", len(variables), len(expected))
+	}
+	for name, value := range expected {
+		variable := variables[name]
+		if variable == nil {
+			test.Fatalf("session has no variable %q", name)
+		}
+		if variable.Name() != name {
+			test.Fatalf("variable %q is named %q", name, variable.Name())
+		}
+		if variable.String() != value {
+			test.Fatalf("variable %q is %q, expected %q", name, variable.String(), value)
+		}
+	}
+}
+
+func TestCloudSessionSend (test *testing.T) {
+	server  := newCloudServer(test)
+	session := server.connect(test)
+
+	expectMessage := func (fields map[string] any) {
+		test.Helper()
+		message := server.next(test)
+		for key, value := range fields {
+			if message[key] != value {
+				test.Fatalf("message %v has %s %v, expected %v", message, key, message[key], value)
+			}
+		}
+		if message["user"] != "tester" || message["project_id"] != 42.0 {
+			test.Fatalf("message %v has wrong user or project", message)
+		}
+	}
+
+	err := session.SetVariable("score", "10")
+	if err != nil { test.Fatal(err) }
+	expectMessage(map[string] any { "method": "set", "name": "☁ score", "value": "10" })
+	expectVariables(test, session, map[string] string { "☁ score": "10" })
+
+	err = session.CreateVariable("☁ lives", "3")
+	if err != nil { test.Fatal(err) }
+	expectMessage(map[string] any { "method": "create", "name": "☁ lives", "value": "3" })
+	expectVariables(test, session, map[string] string { "☁ score": "10", "☁ lives": "3" })
+
+	err = session.RenameVariable("score", "points")
+	if err != nil { test.Fatal(err) }
+	expectMessage(map[string] any { "method": "rename", "name": "☁ score", "new_name": "☁ points" })
+	expectVariables(test, session, map[string] string { "☁ points": "10", "☁ lives": "3" })
+
+	err = session.DeleteVariable("lives")
+	if err != nil { test.Fatal(err) }
+	expectMessage(map[string] any { "method": "delete", "name": "☁ lives" })
+	expectVariables(test, session, map[string] string { "☁ points": "10" })
+}
+
+func TestCloudSessionReceive (test *testing.T) {
+	server  := newCloudServer(test)
+	session := server.connect(test)
+
+	expectMessage := func (method CloudMethod, name string) (message CloudMessage) {
+		test.Helper()
+		message, err := session.ReadMessage()
+		if err != nil { test.Fatal(err) }
+		if message.Method != method || message.Name != name {
+			test.Fatalf("received %s %q, expected %s %q", message.Method, message.Name, method, name)
+		}
+		return
+	}
+
+	// several messages in one frame, with a numeric value
+	server.send <-
+		`{"method":"set","name":"☁ a","value":"1"}` + "\n" +
+		`{"method":"create","name":"☁ b","value":2}` + "\n" +
+		`{"method":"set","name":"☁ a","value":"5"}` + "\n"
+	expectMessage(CloudMethodSet,    "☁ a")
+	expectMessage(CloudMethodCreate, "☁ b")
+	message := expectMessage(CloudMethodSet, "☁ a")
+	if message.Variable == nil || message.Variable.Float() != 5 {
+		test.Fatalf("message refers to %v, expected variable with 5", message.Variable)
+	}
+	expectVariables(test, session, map[string] string { "☁ a": "5", "☁ b": "2" })
+
+	server.send <- `{"method":"rename","name":"☁ a","new_name":"☁ c"}`
+	message = expectMessage(CloudMethodRename, "☁ a")
+	if message.NewName != "☁ c" || message.Variable.Name() != "☁ c" {
+		test.Fatalf("rename refers to %v", message.Variable)
+	}
+	expectVariables(test, session, map[string] string { "☁ c": "5", "☁ b": "2" })
+
+	server.send <- `{"method":"delete","name":"☁ b"}` + "\n"
+	message = expectMessage(CloudMethodDelete, "☁ b")
+	if message.Variable == nil || message.Variable.String() != "2" {
+		test.Fatalf("delete refers to %v", message.Variable)
+	}
+	expectVariables(test, session, map[string] string { "☁ c": "5" })
+
+	// renaming and deleting unknown variables must not leave stale names
+	server.send <-
+		`{"method":"delete","name":"☁ missing"}` + "\n" +
+		`{"method":"rename","name":"☁ missing","new_name":"☁ d"}`
+	expectMessage(CloudMethodDelete, "☁ missing")
+	expectMessage(CloudMethodRename, "☁ missing")
+	expectVariables(test, session, map[string] string { "☁ c": "5", "☁ d": "" })
+}
+
+func TestParseCloudMessages (test *testing.T) {
+	messages, err := parseCloudMessages([]byte (
+		"{\"method\":\"set\",\"name\":\"☁ x\",\"value\":12.5}\n\n" +
+		"  {\"method\":\"set\",\"name\":\"☁ y\",\"value\":\"hi\"}  \n" +
+		"{\"method\":\"rename\",\"name\":\"☁ y\",\"new_name\":\"☁ z\"}"))
+	if err != nil { test.Fatal(err) }
+
+	expected := []CloudMessage {
+		{ Method: CloudMethodSet,    Name: "☁ x", Value: "12.5" },
+		{ Method: CloudMethodSet,    Name: "☁ y", Value: "hi" },
+		{ Method: CloudMethodRename, Name: "☁ y", NewName: "☁ z" },
+	}
+	if len(messages) != len(expected) {
+		test.Fatalf("parsed %d messages, expected %d", len(messages), len(expected))
+	}
+	for index, message := range messages {
+		if message != expected[index] {
+			test.Fatalf("message %d is %+v, expected %+v", index, message, expected[index])
+		}
+	}
+
+	_, err = parseCloudMessages([]byte("{\"method\":\"set\"}\nnot json"))
+	if err == nil { test.Fatal("invalid message was parsed") }
+}
+
+// cloudProject is a Scratch 3 project with two cloud variables and a local one.
+const cloudProject = `{
+	"targets": [{
+		"isStage": true,
+		"name": "Stage",
+		"variables": {
+			"a": ["☁ score", 10, true],
+			"b": ["local", 1],
+			"c": ["☁ name", "bob", true]
+		}
+	}]
+}`
+
+func TestGetProjectCloudVariables (test *testing.T) {
+	newProjectServer(test, map[string] string {
+		"42": cloudProject,
+		"7":  sb2Project,
+	})
+
+	variables, err := GetProjectCloudVariables(42)
+	if err != nil { test.Fatal(err) }
+	found := []string { }
+	for _, variable := range variables {
+		found = append(found, variable.Name() + " = " + variable.String())
+	}
+	if strings.Join(found, ", ") != "☁ name = bob, ☁ score = 10" {
+		test.Fatalf("cloud variables are %v", found)
+	}
+	if variables[1].Float() != 10 {
+		test.Fatalf("score has the number value %v", variables[1].Float())
+	}
+
+	_, err = GetProjectCloudVariables(7)
+	if err == nil || !strings.Contains(err.Error(), "sb2 format") {
+		test.Fatalf("error is %v, expected the project to be rejected", err)
+	}
+}
+
+func TestCloudSessionWithVariables (test *testing.T) {
+	newProjectServer(test, map[string] string { "42": cloudProject })
+	server := newCloudServer(test)
+
+	session, err := CreateCloudSessionWithVariables(&UserSession { username: "tester" }, 42)
+	if err != nil { test.Fatal(err) }
+	test.Cleanup(func () { session.Close() })
+	if server.next(test)["method"] != "handshake" {
+		test.Fatal("session did not send a handshake")
+	}
+	expectVariables(test, session, map[string] string {
+		"☁ score": "10",
+		"☁ name":  "bob",
+	})
+
+	// loading again does not replace values sent by the server
+	server.send <- `{"method":"set","name":"☁ score","value":"99"}` + "\n"
+	_, err = session.ReadMessage()
+	if err != nil { test.Fatal(err) }
+	err = session.LoadVariables()
+	if err != nil { test.Fatal(err) }
+	expectVariables(test, session, map[string] string {
+		"☁ score": "99",
+		"☁ name":  "bob",
+	})
+
+	// the session is not created if the variables cannot be loaded
+	_, err = CreateCloudSessionWithVariables(&UserSession { username: "tester" }, 8)
+	if err == nil { test.Fatal("session was created for a missing project") }
+}
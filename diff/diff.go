@@ -0,0 +1,368 @@
+/* Package diff compares two versions of a Scratch 3 project, and reports what
+ * was added, removed, and modified between them.
+ *
+ * Things are matched by what they are rather than by their IDs, which change
+ * whenever a project is remixed or blocks are copied. Sprites, variables,
+ * lists, costumes, and sounds are matched by name, and scripts are matched by
+ * their contents, falling back to the hat block they start with.
+ */
+package diff
+
+import "fmt"
+import "strings"
+import "github.com/scapi3/sb3"
+
+/* ChangeKind describes what happened to an item.
+ */
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+/* ItemKind describes what kind of item changed.
+ */
+type ItemKind string
+
+const (
+	ItemSprite   ItemKind = "sprite"
+	ItemScript   ItemKind = "script"
+	ItemBlock    ItemKind = "block"
+	ItemVariable ItemKind = "variable"
+	ItemList     ItemKind = "list"
+	ItemCostume  ItemKind = "costume"
+	ItemSound    ItemKind = "sound"
+)
+
+/* Change is a single difference between two projects.
+ */
+type Change struct {
+	Kind ChangeKind
+	Item ItemKind
+
+	// Target is the name of the sprite that the item belongs to, or the
+	// name of the stage.
+	Target string
+
+	// Script is the first line of the script that a block belongs to,
+	// and is only used for block changes.
+	Script string
+
+	// Name identifies the item. Scripts are named by their first line, and
+	// blocks by their line in the script, both written in the
+	// scratchblocks syntax.
+	Name string
+
+	// Detail describes a modification, or summarizes an added or removed
+	// sprite.
+	Detail string
+}
+
+/* String returns a short description of the change.
+ */
+func (change Change) String () (description string) {
+	description = fmt.Sprintf (
+		"%s: %s %s %s",
+		change.Target, change.Kind, change.Item, change.Name)
+	if change.Detail != "" { description += " (" + change.Detail + ")" }
+	return
+}
+
+/* Diff holds the differences between two projects, grouped by target. Targets
+ * come in the order that they have in the newer project, followed by targets
+ * that were removed.
+ */
+type Diff struct {
+	Changes []Change
+}
+
+/* Empty returns whether there are no differences.
+ */
+func (diff Diff) Empty () (empty bool) {
+	return len(diff.Changes) == 0
+}
+
+/* Compare finds the differences between an older and a newer version of a
+ * project.
+ */
+func Compare (older, newer *sb3.Project) (diff Diff) {
+	matched := map[*sb3.Target] bool { }
+	for _, target := range newer.Targets {
+		old := findTarget(older, target)
+		if old == nil {
+			diff.Changes = append(diff.Changes, Change {
+				Kind:   ChangeAdded,
+				Item:   ItemSprite,
+				Target: target.Name,
+				Name:   target.Name,
+				Detail: summarize(target),
+			})
+			continue
+		}
+
+		matched[old] = true
+		diff.Changes = append(diff.Changes, compareTargets(old, target)...)
+	}
+
+	for _, target := range older.Targets {
+		if matched[target] { continue }
+		diff.Changes = append(diff.Changes, Change {
+			Kind:   ChangeRemoved,
+			Item:   ItemSprite,
+			Target: target.Name,
+			Name:   target.Name,
+			Detail: summarize(target),
+		})
+	}
+	return
+}
+
+/* findTarget finds the target in a project that matches the specified target.
+ * The stage matches the stage, and sprites are matched by name.
+ */
+func findTarget (project *sb3.Project, match *sb3.Target) (target *sb3.Target) {
+	for _, target := range project.Targets {
+		if target.IsStage != match.IsStage { continue }
+		if target.IsStage || target.Name == match.Name { return target }
+	}
+	return
+}
+
+/* summarize describes the contents of a target.
+ */
+func summarize (target *sb3.Target) (summary string) {
+	return fmt.Sprintf (
+		"%s, %s, %s",
+		plural(len(target.Scripts()), "script"),
+		plural(len(target.Costumes),  "costume"),
+		plural(len(target.Sounds),    "sound"))
+}
+
+/* plural formats an amount of things.
+ */
+func plural (count int, noun string) (text string) {
+	if count == 1 { return "1 " + noun }
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+/* compareTargets finds the differences between two versions of a target.
+ */
+func compareTargets (older, newer *sb3.Target) (changes []Change) {
+	properties := compareProperties(older, newer)
+	if len(properties) > 0 {
+		changes = append(changes, Change {
+			Kind:   ChangeModified,
+			Item:   ItemSprite,
+			Target: newer.Name,
+			Name:   newer.Name,
+			Detail: strings.Join(properties, ", "),
+		})
+	}
+
+	changes = append(changes, compareScripts(older, newer)...)
+	changes = append(changes, compareVariables(older, newer)...)
+	changes = append(changes, compareLists(older, newer)...)
+	changes = append(changes, compareCostumes(older, newer)...)
+	changes = append(changes, compareSounds(older, newer)...)
+	return
+}
+
+/* compareProperties returns the names of the properties of a target that are
+ * different between two versions of it.
+ */
+func compareProperties (older, newer *sb3.Target) (names []string) {
+	check := func (name string, different bool) {
+		if different { names = append(names, name) }
+	}
+
+	if older.Name != newer.Name {
+		names = append(names, "name " + older.Name)
+	}
+	check("current costume", older.CurrentCostume != newer.CurrentCostume)
+	check("volume",          older.Volume         != newer.Volume)
+	check("layer",           older.LayerOrder     != newer.LayerOrder)
+	if newer.IsStage {
+		check("tempo",      older.Tempo      != newer.Tempo)
+		check("video",      older.VideoState != newer.VideoState)
+		return
+	}
+	check("visible",        older.Visible       != newer.Visible)
+	check("position",       older.X != newer.X || older.Y != newer.Y)
+	check("size",           older.Size          != newer.Size)
+	check("direction",      older.Direction     != newer.Direction)
+	check("draggable",      older.Draggable     != newer.Draggable)
+	check("rotation style", older.RotationStyle != newer.RotationStyle)
+	return
+}
+
+/* compareVariables finds variables that were added, removed, or modified
+ * between two versions of a target.
+ */
+func compareVariables (older, newer *sb3.Target) (changes []Change) {
+	olderByName := map[string] *sb3.Variable { }
+	for _, variable := range older.Variables {
+		olderByName[variable.Name] = variable
+	}
+	newerByName := map[string] *sb3.Variable { }
+	for _, variable := range newer.Variables {
+		newerByName[variable.Name] = variable
+	}
+
+	for _, name := range sb3.SortedKeys(newerByName) {
+		variable := newerByName[name]
+		old, ok := olderByName[name]
+		if !ok {
+			changes = append(changes, change (
+				ChangeAdded, ItemVariable, newer, name, ""))
+			continue
+		}
+
+		details := []string { }
+		if old.IsCloud != variable.IsCloud {
+			if variable.IsCloud {
+				details = append(details, "now cloud")
+			} else {
+				details = append(details, "no longer cloud")
+			}
+		}
+		if fmt.Sprint(old.Value) != fmt.Sprint(variable.Value) {
+			details = append(details, fmt.Sprintf (
+				"value %v -> %v", old.Value, variable.Value))
+		}
+		if len(details) > 0 {
+			changes = append(changes, change (
+				ChangeModified, ItemVariable, newer, name,
+				strings.Join(details, ", ")))
+		}
+	}
+
+	for _, name := range sb3.SortedKeys(olderByName) {
+		if _, ok := newerByName[name]; ok { continue }
+		changes = append(changes, change (
+			ChangeRemoved, ItemVariable, newer, name, ""))
+	}
+	return
+}
+
+/* compareLists finds lists that were added, removed, or modified between two
+ * versions of a target.
+ */
+func compareLists (older, newer *sb3.Target) (changes []Change) {
+	olderByName := map[string] *sb3.List { }
+	for _, list := range older.Lists {
+		olderByName[list.Name] = list
+	}
+	newerByName := map[string] *sb3.List { }
+	for _, list := range newer.Lists {
+		newerByName[list.Name] = list
+	}
+
+	for _, name := range sb3.SortedKeys(newerByName) {
+		list := newerByName[name]
+		old, ok := olderByName[name]
+		if !ok {
+			changes = append(changes, change (
+				ChangeAdded, ItemList, newer, name, ""))
+			continue
+		}
+		if fmt.Sprint(old.Values) != fmt.Sprint(list.Values) {
+			changes = append(changes, change (
+				ChangeModified, ItemList, newer, name,
+				fmt.Sprintf (
+					"contents changed, %s -> %s",
+					plural(len(old.Values),  "item"),
+					plural(len(list.Values), "item"))))
+		}
+	}
+
+	for _, name := range sb3.SortedKeys(olderByName) {
+		if _, ok := newerByName[name]; ok { continue }
+		changes = append(changes, change (
+			ChangeRemoved, ItemList, newer, name, ""))
+	}
+	return
+}
+
+/* compareCostumes finds costumes that were added, removed, or modified between
+ * two versions of a target. A costume is modified if its image or its rotation
+ * center changed.
+ */
+func compareCostumes (older, newer *sb3.Target) (changes []Change) {
+	for _, costume := range newer.Costumes {
+		old := older.Costume(costume.Name)
+		if old == nil {
+			changes = append(changes, change (
+				ChangeAdded, ItemCostume, newer, costume.Name, ""))
+			continue
+		}
+
+		details := []string { }
+		if old.AssetID != costume.AssetID {
+			details = append(details, "image changed")
+		}
+		if old.RotationCenterX != costume.RotationCenterX ||
+			old.RotationCenterY != costume.RotationCenterY {
+			details = append(details, "rotation center changed")
+		}
+		if len(details) > 0 {
+			changes = append(changes, change (
+				ChangeModified, ItemCostume, newer, costume.Name,
+				strings.Join(details, ", ")))
+		}
+	}
+
+	for _, costume := range older.Costumes {
+		if newer.Costume(costume.Name) != nil { continue }
+		changes = append(changes, change (
+			ChangeRemoved, ItemCostume, newer, costume.Name, ""))
+	}
+	return
+}
+
+/* compareSounds finds sounds that were added, removed, or modified between two
+ * versions of a target. A sound is modified if its audio changed.
+ */
+func compareSounds (older, newer *sb3.Target) (changes []Change) {
+	for _, sound := range newer.Sounds {
+		old := older.Sound(sound.Name)
+		if old == nil {
+			changes = append(changes, change (
+				ChangeAdded, ItemSound, newer, sound.Name, ""))
+			continue
+		}
+		if old.AssetID != sound.AssetID {
+			changes = append(changes, change (
+				ChangeModified, ItemSound, newer, sound.Name,
+				"audio changed"))
+		}
+	}
+
+	for _, sound := range older.Sounds {
+		if newer.Sound(sound.Name) != nil { continue }
+		changes = append(changes, change (
+			ChangeRemoved, ItemSound, newer, sound.Name, ""))
+	}
+	return
+}
+
+/* change creates a change to an item of a target.
+ */
+func change (
+	kind   ChangeKind,
+	item   ItemKind,
+	target *sb3.Target,
+	name   string,
+	detail string,
+) (
+	created Change,
+) {
+	return Change {
+		Kind:   kind,
+		Item:   item,
+		Target: target.Name,
+		Name:   name,
+		Detail: detail,
+	}
+}
@@ -0,0 +1,78 @@
+package diff
+
+import "strings"
+import "testing"
+import "github.com/scapi3/sb3"
+
+/* newTarget creates an empty target for a test project.
+ */
+func newTarget (name string, stage bool) (target *sb3.Target) {
+	return &sb3.Target {
+		IsStage:   stage,
+		Name:      name,
+		Variables: map[string] *sb3.Variable { },
+		Lists:     map[string] *sb3.List { },
+		Blocks:    map[string] *sb3.Block { },
+		Comments:  map[string] *sb3.Comment { },
+		Costumes:  []*sb3.Costume {
+			{ AssetID: "a", Name: "costume1", DataFormat: "svg" },
+		},
+		Visible: true,
+		Size:    100,
+	}
+}
+
+/* expectChanges checks the descriptions of a list of changes.
+ */
+func expectChanges (test *testing.T, changes []Change, expected ...string) {
+	test.Helper()
+	descriptions := []string { }
+	for _, change := range changes {
+		descriptions = append(descriptions, change.String())
+	}
+	if strings.Join(descriptions, "\n") != strings.Join(expected, "\n") {
+		test.Fatalf (
+			"changes are:\n%s\nexpected:\n%s",
+			strings.Join(descriptions, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestCompareByName (test *testing.T) {
+	olderStage := newTarget("Stage", true)
+	olderStage.Variables["v1"] = &sb3.Variable { Name: "score", Value: 0 }
+	olderStage.Lists["l1"]     = &sb3.List { Name: "items", Values: []any { "a" } }
+	olderCat := newTarget("Cat", false)
+	olderCat.Sounds = []*sb3.Sound { { AssetID: "p", Name: "pop" } }
+	older := &sb3.Project { Targets: []*sb3.Target {
+		olderStage, olderCat, newTarget("Dog", false),
+	} }
+
+	// items with the same names are matched even though their IDs changed
+	newerStage := newTarget("Stage", true)
+	newerStage.Variables["v2"] = &sb3.Variable { Name: "score", Value: 5 }
+	newerStage.Variables["v3"] = &sb3.Variable { Name: "lives", Value: 3 }
+	newerStage.Lists["l2"]     = &sb3.List { Name: "items", Values: []any { "a" } }
+	newerCat := newTarget("Cat", false)
+	newerCat.X = 10
+	newerCat.Costumes[0].AssetID = "b"
+	newerCat.Costumes = append(newerCat.Costumes, &sb3.Costume { AssetID: "c", Name: "new" })
+	newerCat.Sounds = []*sb3.Sound { { AssetID: "p", Name: "pop" } }
+	newer := &sb3.Project { Targets: []*sb3.Target {
+		newerStage, newTarget("Bird", false), newerCat,
+	} }
+
+	diff := Compare(older, newer)
+	expectChanges (
+		test, diff.Changes,
+		"Stage: added variable lives",
+		"Stage: modified variable score (value 0 -> 5)",
+		"Bird: added sprite Bird (0 scripts, 1 costume, 0 sounds)",
+		"Cat: modified sprite Cat (position)",
+		"Cat: modified costume costume1 (image changed)",
+		"Cat: added costume new",
+		"Dog: removed sprite Dog (0 scripts, 1 costume, 0 sounds)")
+
+	if !Compare(older, older).Empty() {
+		test.Fatal("project is different from itself")
+	}
+}
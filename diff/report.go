@@ -0,0 +1,40 @@
+package diff
+
+import "strings"
+
+// kindSymbols holds the symbols that are written before changes in reports.
+var kindSymbols = map[ChangeKind] string {
+	ChangeAdded:    "+",
+	ChangeRemoved:  "-",
+	ChangeModified: "~",
+}
+
+/* String formats the differences as a text report. Changes are listed under
+ * the name of the target they belong to, and the blocks that were added to or
+ * removed from a script are listed under the script.
+ */
+func (diff Diff) String () (report string) {
+	builder := strings.Builder { }
+	target  := ""
+	for _, change := range diff.Changes {
+		if change.Target != target {
+			target = change.Target
+			if builder.Len() > 0 { builder.WriteString("\n") }
+			builder.WriteString(target + "\n")
+		}
+
+		indent := "  "
+		if change.Item == ItemBlock { indent = "      " }
+		builder.WriteString(indent)
+		builder.WriteString(kindSymbols[change.Kind] + " ")
+		if change.Item != ItemBlock {
+			builder.WriteString(string(change.Item) + " ")
+		}
+		builder.WriteString(change.Name)
+		if change.Detail != "" {
+			builder.WriteString(" (" + change.Detail + ")")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
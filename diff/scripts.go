@@ -0,0 +1,195 @@
+package diff
+
+import "strings"
+import "github.com/scapi3/sb3"
+import "github.com/scapi3/scratchblocks"
+
+/* script is a script that has been rendered so that it can be compared with
+ * other scripts regardless of block IDs and positions.
+ */
+type script struct {
+	// text is the whole script in the scratchblocks syntax, which serves
+	// as its fingerprint.
+	text string
+
+	// signature is the first line of the script, which describes its hat
+	// block, or its first block if it has no hat.
+	signature string
+
+	lines []string
+}
+
+/* renderScripts renders every script of a target.
+ */
+func renderScripts (target *sb3.Target) (scripts []*script) {
+	for _, id := range target.Scripts() {
+		text  := scratchblocks.RenderScript(target, id)
+		lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+		// leave out the comment on the first block, so that editing it
+		// does not stop the script from being matched
+		signature := lines[0]
+		block := target.Blocks[id]
+		if block.Comment != "" && target.Comments[block.Comment] != nil {
+			index := strings.LastIndex(signature, " // ")
+			if index >= 0 { signature = signature[:index] }
+		}
+
+		scripts = append(scripts, &script {
+			text:      text,
+			signature: signature,
+			lines:     lines,
+		})
+	}
+	return
+}
+
+/* compareScripts finds scripts that were added, removed, or modified between
+ * two versions of a target. Scripts that are identical are matched first.
+ * Then, the scripts that are left are matched by their signature, preferring
+ * pairs that have the most lines in common. Matched scripts that differ are
+ * reported as modified, along with the blocks that were added and removed.
+ */
+func compareScripts (older, newer *sb3.Target) (changes []Change) {
+	olderScripts := renderScripts(older)
+	newerScripts := renderScripts(newer)
+	matched := map[*script] *script { }
+	used    := map[*script] bool { }
+
+	for _, current := range newerScripts {
+		for _, previous := range olderScripts {
+			if used[previous] || previous.text != current.text { continue }
+			matched[current] = previous
+			used[previous] = true
+			break
+		}
+	}
+
+	for _, current := range newerScripts {
+		if matched[current] != nil { continue }
+		var best *script
+		bestCommon := -1
+		for _, previous := range olderScripts {
+			if used[previous] { continue }
+			if previous.signature != current.signature { continue }
+			common := len(commonLines(previous.lines, current.lines))
+			if common > bestCommon {
+				best, bestCommon = previous, common
+			}
+		}
+		if best == nil { continue }
+		matched[current] = best
+		used[best] = true
+	}
+
+	for _, current := range newerScripts {
+		previous := matched[current]
+		if previous == nil {
+			changes = append(changes, change (
+				ChangeAdded, ItemScript, newer, current.signature, ""))
+			continue
+		}
+		if previous.text == current.text { continue }
+
+		added, removed := diffLines(previous.lines, current.lines)
+		changes = append(changes, change (
+			ChangeModified, ItemScript, newer, current.signature,
+			plural(len(added), "block") + " added, " +
+			plural(len(removed), "block") + " removed"))
+		for _, line := range removed {
+			changes = append(changes, blockChange (
+				ChangeRemoved, newer, current.signature, line))
+		}
+		for _, line := range added {
+			changes = append(changes, blockChange (
+				ChangeAdded, newer, current.signature, line))
+		}
+	}
+
+	for _, previous := range olderScripts {
+		if used[previous] { continue }
+		changes = append(changes, change (
+			ChangeRemoved, ItemScript, newer, previous.signature, ""))
+	}
+	return
+}
+
+/* blockChange creates a change to a block in a script.
+ */
+func blockChange (
+	kind   ChangeKind,
+	target *sb3.Target,
+	script string,
+	line   string,
+) (
+	created Change,
+) {
+	created = change(kind, ItemBlock, target, strings.TrimSpace(line), "")
+	created.Script = script
+	return
+}
+
+/* diffLines returns the lines that were added and removed between two
+ * versions of a script. The lines that end C blocks are left out, because they
+ * do not represent blocks.
+ */
+func diffLines (older, newer []string) (added, removed []string) {
+	common := commonLines(older, newer)
+	isBlock := func (line string) bool {
+		line = strings.TrimSpace(line)
+		return line != "end" && line != "else"
+	}
+
+	olderIndex, newerIndex := 0, 0
+	for _, pair := range append(common, [2]int { len(older), len(newer) }) {
+		for ; olderIndex < pair[0]; olderIndex ++ {
+			line := older[olderIndex]
+			if isBlock(line) { removed = append(removed, line) }
+		}
+		for ; newerIndex < pair[1]; newerIndex ++ {
+			line := newer[newerIndex]
+			if isBlock(line) { added = append(added, line) }
+		}
+		olderIndex ++
+		newerIndex ++
+	}
+	return
+}
+
+/* commonLines finds the longest common subsequence of two lists of lines, and
+ * returns the indices of its lines in both lists.
+ */
+func commonLines (older, newer []string) (pairs [][2]int) {
+	lengths := make([][]int, len(older) + 1)
+	for index := range lengths {
+		lengths[index] = make([]int, len(newer) + 1)
+	}
+	for olderIndex := len(older) - 1; olderIndex >= 0; olderIndex -- {
+		for newerIndex := len(newer) - 1; newerIndex >= 0; newerIndex -- {
+			row := lengths[olderIndex]
+			if older[olderIndex] == newer[newerIndex] {
+				row[newerIndex] = lengths[olderIndex + 1][newerIndex + 1] + 1
+			} else if lengths[olderIndex + 1][newerIndex] > row[newerIndex + 1] {
+				row[newerIndex] = lengths[olderIndex + 1][newerIndex]
+			} else {
+				row[newerIndex] = row[newerIndex + 1]
+			}
+		}
+	}
+
+	olderIndex, newerIndex := 0, 0
+	for olderIndex < len(older) && newerIndex < len(newer) {
+		switch {
+		case older[olderIndex] == newer[newerIndex]:
+			pairs = append(pairs, [2]int { olderIndex, newerIndex })
+			olderIndex ++
+			newerIndex ++
+		case lengths[olderIndex + 1][newerIndex] >=
+			lengths[olderIndex][newerIndex + 1]:
+			olderIndex ++
+		default:
+			newerIndex ++
+		}
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package diff
+
+import "fmt"
+import "strings"
+import "testing"
+import "github.com/scapi3/sb3"
+
+// testInputs holds the inputs given to blocks in test scripts.
+var testInputs = map[string] map[string] string {
+	"motion_movesteps": { "STEPS":   "10" },
+	"motion_turnright": { "DEGREES": "15" },
+}
+
+/* addScript adds a script made of the specified opcodes to a target. The IDs of
+ * its blocks start with prefix.
+ */
+func addScript (target *sb3.Target, prefix string, y float64, opcodes ...string) {
+	parent := ""
+	for index, opcode := range opcodes {
+		id := fmt.Sprint(prefix, index)
+		block := &sb3.Block {
+			ID:       id,
+			Opcode:   opcode,
+			Parent:   parent,
+			Inputs:   map[string] sb3.Input { },
+			Fields:   map[string] sb3.Field { },
+			TopLevel: parent == "",
+			Y:        y,
+		}
+		for name, value := range testInputs[opcode] {
+			block.Inputs[name] = sb3.Input {
+				ShadowType: sb3.ShadowTypeSame,
+				Block: sb3.InputValue { Primitive: &sb3.Primitive {
+					Type:  sb3.PrimitiveTypeMathNumber,
+					Value: value,
+				} },
+			}
+		}
+		if parent != "" { target.Blocks[parent].Next = id }
+		target.Blocks[id] = block
+		parent = id
+	}
+}
+
+func TestCompareScripts (test *testing.T) {
+	older := newTarget("Cat", false)
+	addScript(older, "a", 0,   "event_whenthisspriteclicked", "motion_movesteps")
+	addScript(older, "b", 100, "event_whenflagclicked", "motion_movesteps", "motion_ifonedgebounce")
+	addScript(older, "c", 200, "event_whenflagclicked", "motion_turnright")
+	addScript(older, "d", 300, "control_start_as_clone", "motion_ifonedgebounce")
+
+	// the sprite click script only moved and was given new IDs, so it is
+	// matched by its text, and the second one is added. The green flag
+	// scripts are matched with the ones they have the most lines in common
+	// with.
+	newer := newTarget("Cat", false)
+	addScript(newer, "e", 0,   "event_whenflagclicked", "motion_turnright", "motion_turnright")
+	addScript(newer, "f", 100, "event_whenflagclicked", "motion_ifonedgebounce")
+	addScript(newer, "g", 200, "event_whenthisspriteclicked", "motion_movesteps")
+	addScript(newer, "h", 300, "event_whenthisspriteclicked")
+
+	expectChanges (
+		test, compareScripts(older, newer),
+		"Cat: modified script when green flag clicked (1 block added, 0 blocks removed)",
+		"Cat: added block turn @turnRight (15) degrees",
+		"Cat: modified script when green flag clicked (0 blocks added, 1 block removed)",
+		"Cat: removed block move (10) steps",
+		"Cat: added script when this sprite clicked",
+		"Cat: removed script when I start as a clone")
+}
+
+func TestDiffLines (test *testing.T) {
+	cases := []struct {
+		older   string
+		newer   string
+		common  string
+		added   string
+		removed string
+	} {
+		{ "a b c", "a b c", "0:0 1:1 2:2", "", "" },
+		{ "a b c", "a c", "0:0 2:1", "", "b" },
+		{ "a c", "a b c", "0:0 1:2", "b", "" },
+		{ "a b c d", "b x d", "1:0 3:2", "x", "a c" },
+		{ "a end", "a b end", "0:0 1:2", "b", "" },
+		{ "", "a", "", "a", "" },
+	}
+
+	for _, testCase := range cases {
+		older := strings.Fields(testCase.older)
+		newer := strings.Fields(testCase.newer)
+
+		pairs := []string { }
+		for _, pair := range commonLines(older, newer) {
+			pairs = append(pairs, fmt.Sprintf("%d:%d", pair[0], pair[1]))
+		}
+		if strings.Join(pairs, " ") != testCase.common {
+			test.Errorf (
+				"common lines of %q and %q are %v, expected %s",
+				testCase.older, testCase.newer, pairs, testCase.common)
+		}
+
+		added, removed := diffLines(older, newer)
+		if strings.Join(added, " ") != testCase.added ||
+			strings.Join(removed, " ") != testCase.removed {
+			test.Errorf (
+				"%q -> %q added %v and removed %v, expected %q and %q",
+				testCase.older, testCase.newer, added, removed,
+				testCase.added, testCase.removed)
+		}
+	}
+}
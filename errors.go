@@ -0,0 +1,61 @@
+package scapi3
+
+import "fmt"
+import "errors"
+import "net/http"
+
+/* ErrNotFound is matched by errors returned when the target of an action does
+ * not exist.
+ */
+var ErrNotFound = errors.New("not found")
+
+/* ErrRejected is matched by errors returned when the Scratch servers refuse to
+ * perform an action.
+ */
+var ErrRejected = errors.New("rejected")
+
+/* ActionError is returned when the Scratch servers respond to an action with an
+ * unsuccessful status code. It can be checked against ErrNotFound and
+ * ErrRejected using errors.Is.
+ */
+type ActionError struct {
+	Action     string
+	Target     string
+	Status     string
+	StatusCode int
+}
+
+/* Error returns a description of the error.
+ */
+func (err *ActionError) Error () (description string) {
+	return fmt.Sprintf("cannot %s %s (%s)", err.Action, err.Target, err.Status)
+}
+
+/* Unwrap returns ErrNotFound if the target of the action does not exist, and
+ * ErrRejected otherwise.
+ */
+func (err *ActionError) Unwrap () (wrapped error) {
+	if err.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return ErrRejected
+}
+
+/* checkAction returns an ActionError if the response's status code does not
+ * indicate success, and nil otherwise.
+ */
+func checkAction (
+	action   string,
+	target   string,
+	response *http.Response,
+) (
+	err error,
+) {
+	if response.StatusCode >= 200 && response.StatusCode < 300 { return }
+	return &ActionError {
+		Action:     action,
+		Target:     target,
+		Status:     response.Status,
+		StatusCode: response.StatusCode,
+	}
+}
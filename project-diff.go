@@ -0,0 +1,76 @@
+package scapi3
+
+import "fmt"
+import "github.com/scapi3/sb2"
+import "github.com/scapi3/diff"
+
+/* CompareRemix downloads a shared remix along with the project it was remixed
+ * from, and returns the differences between them. See CompareProjects.
+ */
+func CompareRemix (id uint64) (
+	changes      diff.Diff,
+	parentReport sb2.Report,
+	remixReport  sb2.Report,
+	err          error,
+) {
+	return anonymous.CompareRemix(id)
+}
+
+/* CompareProjects downloads two shared projects, such as two submissions of
+ * the same assignment, and returns the differences between them.
+ */
+func CompareProjects (older, newer uint64) (
+	changes     diff.Diff,
+	olderReport sb2.Report,
+	newerReport sb2.Report,
+	err         error,
+) {
+	return anonymous.CompareProjects(older, newer)
+}
+
+/* CompareRemix downloads a remix along with the project it was remixed from,
+ * and returns the differences between them. Unshared projects owned by the
+ * session's user can be compared as well. See CompareProjects.
+ */
+func (session *UserSession) CompareRemix (
+	id uint64,
+) (
+	changes      diff.Diff,
+	parentReport sb2.Report,
+	remixReport  sb2.Report,
+	err          error,
+) {
+	info, err := session.GetProject(id)
+	if err != nil { return }
+	if info.Remix.Parent == 0 {
+		err = fmt.Errorf("project %d is not a remix", id)
+		return
+	}
+	return session.CompareProjects(info.Remix.Parent, id)
+}
+
+/* CompareProjects downloads two projects and returns the differences between
+ * them. Unshared projects owned by the session's user can be compared as well.
+ *
+ * Scratch 2 projects are converted before they are compared, and the reports
+ * of their conversions are returned. If a report is not empty, parts of that
+ * project could not be converted, and the differences may be missing changes
+ * to those parts.
+ */
+func (session *UserSession) CompareProjects (
+	older uint64,
+	newer uint64,
+) (
+	changes     diff.Diff,
+	olderReport sb2.Report,
+	newerReport sb2.Report,
+	err         error,
+) {
+	olderProject, olderReport, err := session.getSB3Project(older)
+	if err != nil { return }
+	newerProject, newerReport, err := session.getSB3Project(newer)
+	if err != nil { return }
+
+	changes = diff.Compare(olderProject, newerProject)
+	return
+}
@@ -0,0 +1,28 @@
+package scapi3
+
+import "testing"
+
+func TestCompareConvertedProjects (test *testing.T) {
+	newProjectServer(test, map[string] string {
+		"7": sb2Project,
+		"8": `{"objName": "Stage", "scripts": [], "children": []}`,
+	})
+
+	changes, olderReport, newerReport, err := CompareProjects(8, 7)
+	if err != nil { test.Fatal(err) }
+	if !olderReport.Empty() {
+		test.Fatalf("report of project 8 is %q, expected it to be empty", olderReport)
+	}
+	if newerReport.Unconverted["playDrum"] != 1 {
+		test.Fatalf("report of project 7 is %q, expected playDrum", newerReport)
+	}
+	if changes.Empty() {
+		test.Fatal("differences were not returned")
+	}
+
+	_, olderReport, newerReport, err = CompareProjects(8, 8)
+	if err != nil { test.Fatal(err) }
+	if !olderReport.Empty() || !newerReport.Empty() {
+		test.Fatal("converting a complete project gave a report")
+	}
+}
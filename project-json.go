@@ -0,0 +1,118 @@
+package scapi3
+
+import "fmt"
+import "bytes"
+import "strconv"
+import "net/http"
+import "encoding/json"
+
+/* ProjectFormat represents the format of a project body downloaded from the
+ * projects server.
+ */
+type ProjectFormat int
+
+const (
+	// ProjectFormatUnknown is used for data that is not a recognized project
+	// format.
+	ProjectFormatUnknown ProjectFormat = iota
+
+	// ProjectFormatSB3 is used for Scratch 3 project JSON.
+	ProjectFormatSB3
+
+	// ProjectFormatSB2 is used for Scratch 2 project JSON.
+	ProjectFormatSB2
+
+	// ProjectFormatSB is used for binary Scratch 1.x projects.
+	ProjectFormatSB
+)
+
+/* String returns the name of the project format.
+ */
+func (format ProjectFormat) String () (name string) {
+	switch format {
+	case ProjectFormatSB3: return "sb3"
+	case ProjectFormatSB2: return "sb2"
+	case ProjectFormatSB:  return "sb"
+	default:               return "unknown"
+	}
+}
+
+/* ProjectJSON holds a parsed project body. Data contains the decoded JSON
+ * object for sb3 and sb2 projects, and is nil for other formats.
+ */
+type ProjectJSON struct {
+	Format ProjectFormat
+	Data   map[string] any
+}
+
+/* ParseProjectJSON detects the format of a project body and decodes it. An
+ * error is only returned if the data looks like JSON but cannot be decoded.
+ */
+func ParseProjectJSON (data []byte) (project ProjectJSON, err error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("ScratchV0")) {
+		project.Format = ProjectFormatSB
+		return
+	}
+	if !bytes.HasPrefix(trimmed, []byte("{")) { return }
+
+	err = json.Unmarshal(trimmed, &project.Data)
+	if err != nil {
+		return project, fmt.Errorf("cannot parse project JSON: %v", err)
+	}
+	
+	if _, ok := project.Data["targets"]; ok {
+		project.Format = ProjectFormatSB3
+	} else if _, ok := project.Data["objName"]; ok {
+		project.Format = ProjectFormatSB2
+	} else if _, ok := project.Data["info"]; ok {
+		project.Format = ProjectFormatSB2
+	}
+	return
+}
+
+/* GetProjectJSON downloads the body of a shared project from the projects
+ * server, and returns the raw data along with its parsed form.
+ */
+func GetProjectJSON (id uint64) (data []byte, project ProjectJSON, err error) {
+	return anonymous.GetProjectJSON(id)
+}
+
+/* GetProjectJSON downloads the body of a project from the projects server, and
+ * returns the raw data along with its parsed form. The project's token is
+ * requested first using the session's credentials, so unshared projects owned
+ * by the session's user can be downloaded as well.
+ */
+func (session *UserSession) GetProjectJSON (
+	id uint64,
+) (
+	data    []byte,
+	project ProjectJSON,
+	err     error,
+) {
+	info, err := session.GetProject(id)
+	if err != nil { return }
+
+	request := Request {
+		Hostname: "projects.scratch.mit.edu",
+		Path:     "/" + strconv.FormatUint(id, 10) +
+			"?token=" + info.ProjectToken,
+	}
+	
+	var response *http.Response
+	if session == nil {
+		response, data, err = request.Send()
+	} else {
+		response, data, err = session.send(request)
+	}
+	if err != nil { return }
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf (
+			"cannot get project %d (%s)",
+			id, response.Status)
+		return
+	}
+
+	project, err = ParseProjectJSON(data)
+	return
+}
@@ -1,5 +1,6 @@
 package scapi3
 
+import "strconv"
 import "encoding/json"
 
 /* LoginRequest represents a username and password combination that can
@@ -17,6 +18,16 @@ func (structure LoginRequest) Marshal () (data []byte) {
 	return
 }
 
+/* RawRequestBody represents a request body that is sent as-is.
+ */
+type RawRequestBody []byte
+
+/* Marshal returns the raw request body.
+ */
+func (structure RawRequestBody) Marshal () (data []byte) {
+	return structure
+}
+
 /* CommentRequest represents a comment request.
  */
 type CommentRequest struct {
@@ -42,6 +53,22 @@ func (structure CommentRequest) Marshal () (data []byte) {
 	return
 }
 
+/* CommentIDRequest represents a request that refers to a comment on a user's
+ * profile, such as deleting or reporting it.
+ */
+type CommentIDRequest struct {
+	ID uint64
+}
+
+/* Marshal converts the comment ID request body into a JSON encoded byte slice.
+ */
+func (structure CommentIDRequest) Marshal () (data []byte) {
+	data, _ = json.Marshal(map[string] any {
+		"id": strconv.FormatUint(structure.ID, 10),
+	})
+	return
+}
+
 /* LoginStatusResponse represents a login response.
  */
 type LoginStatusResponse struct {
@@ -76,3 +103,138 @@ type CommentStatusResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+/* UserSessionData holds the credentials of a user session, so that it can be
+ * saved and restored later without logging in again.
+ */
+type UserSessionData struct {
+	Username  string `json:"username"`
+	ID        int    `json:"id"`
+	Token     string `json:"token"`
+	SessionID string `json:"session_id"`
+	CSRFToken string `json:"csrf_token"`
+}
+
+/* SessionInfo represents the response to a /session/ request, and contains
+ * information about the currently logged in user.
+ */
+type SessionInfo struct {
+	User struct {
+		ID           int    `json:"id"`
+		Banned       bool   `json:"banned"`
+		Username     string `json:"username"`
+		Token        string `json:"token"`
+		ThumbnailURL string `json:"thumbnailUrl"`
+		DateJoined   string `json:"dateJoined"`
+		Email        string `json:"email"`
+	} `json:"user"`
+	Permissions struct {
+		Admin            bool `json:"admin"`
+		Scratcher        bool `json:"scratcher"`
+		NewScratcher     bool `json:"new_scratcher"`
+		InvitedScratcher bool `json:"invited_scratcher"`
+		Social           bool `json:"social"`
+		Educator         bool `json:"educator"`
+		EducatorInvitee  bool `json:"educator_invitee"`
+		Student          bool `json:"student"`
+	} `json:"permissions"`
+	Flags struct {
+		MustResetPassword               bool `json:"must_reset_password"`
+		MustCompleteRegistration        bool `json:"must_complete_registration"`
+		HasOutstandingEmailConfirmation bool `json:"has_outstanding_email_confirmation"`
+		ShowWelcome                     bool `json:"show_welcome"`
+		ConfirmEmailBanner              bool `json:"confirm_email_banner"`
+		UnsupportedBrowserBanner        bool `json:"unsupported_browser_banner"`
+		ProjectCommentsEnabled          bool `json:"project_comments_enabled"`
+		GalleryCommentsEnabled          bool `json:"gallery_comments_enabled"`
+		UserprofileCommentsEnabled      bool `json:"userprofile_comments_enabled"`
+	} `json:"flags"`
+}
+
+/* LoveStatusResponse represents whether a user has loved a project.
+ */
+type LoveStatusResponse struct {
+	UserLove bool `json:"userLove"`
+}
+
+/* FavoriteStatusResponse represents whether a user has favorited a project.
+ */
+type FavoriteStatusResponse struct {
+	UserFavorite bool `json:"userFavorite"`
+}
+
+/* StudioUpdateRequest represents a change to a studio's title or description.
+ * Fields that are nil are left unchanged.
+ */
+type StudioUpdateRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+/* Marshal converts the studio update request body into a JSON encoded byte
+ * slice.
+ */
+func (structure StudioUpdateRequest) Marshal () (data []byte) {
+	data, _ = json.Marshal(structure)
+	return
+}
+
+/* TransferHostRequest represents a request to transfer ownership of a studio,
+ * which must be confirmed with the current host's password.
+ */
+type TransferHostRequest struct {
+	Password string `json:"password"`
+}
+
+/* Marshal converts the transfer host request body into a JSON encoded byte
+ * slice.
+ */
+func (structure TransferHostRequest) Marshal () (data []byte) {
+	data, _ = json.Marshal(structure)
+	return
+}
+
+/* ProjectUpdateRequest represents a change to a project's metadata. Fields that
+ * are nil are left unchanged.
+ */
+type ProjectUpdateRequest struct {
+	Title           *string `json:"title,omitempty"`
+	Instructions    *string `json:"instructions,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	CommentsAllowed *bool   `json:"comments_allowed,omitempty"`
+}
+
+/* Marshal converts the project update request body into a JSON encoded byte
+ * slice.
+ */
+func (structure ProjectUpdateRequest) Marshal () (data []byte) {
+	data, _ = json.Marshal(structure)
+	return
+}
+
+/* ProjectUploadResponse represents the response from the projects server
+ * after a project has been created or updated.
+ */
+type ProjectUploadResponse struct {
+	Status       string      `json:"status"`
+	ContentName  json.Number `json:"content-name"`
+	ContentTitle string      `json:"content-title"`
+}
+
+/* ProfileUpdateRequest represents a change to a user's profile. Fields that
+ * are nil are left unchanged.
+ */
+type ProfileUpdateRequest struct {
+	Bio                  *string `json:"bio,omitempty"`
+	Status               *string `json:"status,omitempty"`
+	FeaturedProject      *uint64 `json:"featured_project,omitempty"`
+	FeaturedProjectLabel *string `json:"featured_project_label,omitempty"`
+}
+
+/* Marshal converts the profile update request body into a JSON encoded byte
+ * slice.
+ */
+func (structure ProfileUpdateRequest) Marshal () (data []byte) {
+	data, _ = json.Marshal(structure)
+	return
+}
@@ -12,8 +12,23 @@ const (
 	MethodGet = iota
 	MethodPost
 	MethodOptions
+	MethodDelete
+	MethodPut
+	MethodHead
 )
 
+// Client is the HTTP client that requests are sent with. Requests that have a
+// cookie jar are sent with a copy of it that uses the jar.
+var Client = http.DefaultClient
+
+/* BaseURL returns the scheme and host that requests for a Scratch hostname are
+ * sent to. It can be replaced to send requests to a stand-in server, such as in
+ * tests.
+ */
+var BaseURL = func (hostname string) (base string) {
+	return "https://" + hostname
+}
+
 /* Request represents an HTTP request made to the Scratch API.
  */
 type Request struct {
@@ -23,6 +38,12 @@ type Request struct {
 	Headers		map[string] string
 	Body		RequestBody
 	SessionID	string
+
+	// Jar holds the cookies sent with the request, and receives the
+	// cookies set by the response. If it is nil, placeholder CSRF
+	// cookies are sent instead, along with SessionID if it is set.
+	Jar		http.CookieJar
+	CSRFToken	string
 }
 
 /* RequestBody represents a structure that can be marshalled into a byte
@@ -32,12 +53,34 @@ type RequestBody interface {
 	Marshal () (data []byte)
 }
 
-/* Send sends the request to the Scratch servers, and returns the response.
+/* Send sends the request to the Scratch servers, and returns the response
+ * along with its entire body.
  */
 func (request Request) Send () (
 	response	*http.Response,
 	body		[]byte,
 	err		error,
+) {
+	response, err = request.Stream()
+	if err != nil { return }
+	defer response.Body.Close()
+	
+	// dump, _ := httputil.DumpResponse(response, false)
+	// println(string(dump))
+	
+	// read response body
+	body, err = ioutil.ReadAll(response.Body)
+	if err != nil { return }
+	return
+}
+
+/* Stream sends the request to the Scratch servers, and returns the response
+ * without reading its body, so that large bodies can be read as they arrive.
+ * The body of the response must be closed by the caller.
+ */
+func (request Request) Stream () (
+	response	*http.Response,
+	err		error,
 ) {
 	// create request
 	var method string
@@ -46,6 +89,12 @@ func (request Request) Send () (
 		method = "GET"
 	} else if request.Method == MethodPost {
 		method = "POST"
+	} else if request.Method == MethodDelete {
+		method = "DELETE"
+	} else if request.Method == MethodPut {
+		method = "PUT"
+	} else if request.Method == MethodHead {
+		method = "HEAD"
 	} else {
 		method = "OPTIONS"
 	}
@@ -61,36 +110,41 @@ func (request Request) Send () (
 	var httpRequest *http.Request
 	httpRequest, err = http.NewRequest (
 		method,
-		"https://" + request.Hostname + request.Path,
+		BaseURL(request.Hostname) + request.Path,
 		requestBody)
 	if err != nil { return }
 
+	csrfToken := request.CSRFToken
+	if csrfToken == "" && request.Jar == nil {
+		csrfToken = "a"
+	}
+
 	// set request headers
-	httpRequest.Header.Set("X-CSRFToken", "a")
+	if csrfToken != "" {
+		httpRequest.Header.Set("X-CSRFToken", csrfToken)
+	}
 	httpRequest.Header.Set("Referer", "https://scratch.mit.edu")
 	httpRequest.Header.Set("User-Agent", "")
 	for key, value := range request.Headers {
 		httpRequest.Header.Set(key, value)
 	}
-	cookies := "scratchcsrftoken=a; scratchlanguage=en;"
-	if request.SessionID != "" {
-		cookies += " scratchsessionsid=\"" + request.SessionID + "\";"
+
+	client := Client
+	if request.Jar == nil {
+		cookies := "scratchcsrftoken=" + csrfToken + "; scratchlanguage=en;"
+		if request.SessionID != "" {
+			cookies += " scratchsessionsid=\"" + request.SessionID + "\";"
+		}
+		httpRequest.Header.Add("Cookie", cookies)
+	} else {
+		withJar := *Client
+		withJar.Jar = request.Jar
+		client = &withJar
 	}
-        httpRequest.Header.Add("Cookie", cookies)
 
 	// dump, _ := httputil.DumpRequestOut(httpRequest, false)
 	// println(string(dump))
 
 	// perform request
-	response, err = http.DefaultClient.Do(httpRequest)
-	if err != nil { return }
-	defer response.Body.Close()
-	
-	// dump, _ = httputil.DumpResponse(response, false)
-	// println(string(dump))
-	
-	// read response body
-	body, err = ioutil.ReadAll(response.Body)
-	if err != nil { return }
-	return
+	return client.Do(httpRequest)
 }
@@ -146,6 +146,16 @@ type StudioProjectsResponseItem struct {
 	ActorID uint64 `json:"actor_id"`
 }
 
+/* StudioRoleResponse describes the role of a user in a studio. Scratch only
+ * provides it to logged in users.
+ */
+type StudioRoleResponse struct {
+	Manager   bool `json:"manager"`
+	Curator   bool `json:"curator"`
+	Invited   bool `json:"invited"`
+	Following bool `json:"following"`
+}
+
 /* StudioActivityResponse represents a list of recent activity in a studio.
  */
 type StudioActivityResponse []StudioActivityResponseItem
@@ -1,10 +1,12 @@
 package scapi3
 
 import "fmt"
-import "strconv"
 import "net/http"
 import "encoding/json"
 
+// anonymous is used to call UserSession rest methods without logging in.
+var anonymous *UserSession
+
 /* RestRequest performs a generic request to the scratch rest API.
  */
 func RestRequest [T any](
@@ -31,6 +33,44 @@ func RestRequestWithQueryString [T any](
 	queryString string,
 ) (
 	err error,
+) {
+	return SessionRestRequestWithQueryString (
+		nil, structure, path,
+		limit, offset, queryString)
+}
+
+/* SessionRestRequest performs a generic request to the scratch rest API on
+ * behalf of a user session. If session is nil, the request is anonymous.
+ */
+func SessionRestRequest [T any](
+	session   *UserSession,
+	structure *T,
+	path      string,
+	limit     int,
+	offset    int,
+) (
+	err error,
+) {
+	return SessionRestRequestWithQueryString (
+		session, structure, path,
+		limit, offset, "")
+}
+
+/* SessionRestRequestWithQueryString performs a generic request to the scratch
+ * rest API on behalf of a user session with additional query string
+ * parameters. The session's token is sent in the X-Token header, along with
+ * its cookies, which gives access to things like unshared projects. If session
+ * is nil, the request is anonymous.
+ */
+func SessionRestRequestWithQueryString [T any](
+	session     *UserSession,
+	structure   *T,
+	path        string,
+	limit       int,
+	offset      int,
+	queryString string,
+) (
+	err error,
 ) {
 	path += "?"
 	if limit  > 0 { path += fmt.Sprintf("limit=%d&",  limit ) }
@@ -39,10 +79,21 @@ func RestRequestWithQueryString [T any](
 		path += queryString
 	}
 
-	response, body, err := Request {
+	request := Request {
 		Path:     path,
 		Hostname: "api.scratch.mit.edu",
-	}.Send()
+	}
+
+	var response *http.Response
+	var body     []byte
+	if session == nil {
+		response, body, err = request.Send()
+	} else {
+		request.Headers = map[string] string {
+			"X-Token": session.token,
+		}
+		response, body, err = session.send(request)
+	}
 	
 	if err != nil { return }
 	if response.StatusCode != http.StatusOK {
@@ -53,7 +104,7 @@ func RestRequestWithQueryString [T any](
 		return
 	}
 	
-	err = json.Unmarshal(body, &structure)
+	err = json.Unmarshal(body, structure)
 	if err != nil { return }
 	return
 }
@@ -61,34 +112,26 @@ func RestRequestWithQueryString [T any](
 /* GetHealth returns information relating to the health of the scratch website.
  */
 func GetHealth () (structure HealthResponse, err error) {
-	err = RestRequest(&structure, "/health", 0, 0)
-	return
+	return anonymous.GetHealth()
 }
 
 /* GetNews returns recent news articles from the scratch website.
  */
 func GetNews (limit, offset int) (structure NewsResponse, err error) {
-	err = RestRequest(&structure, "/news", limit, offset)
-	return
+	return anonymous.GetNews(limit, offset)
 }
 
 /* GetProjectsCountAll returns the amount of projects that have been uploaded to
  * the site.
  */
 func GetProjectsCountAll () (count uint64, err error) {
-	structure := CountResponse { }
-	err = RestRequest(&structure, "/projects/count/all", 0, 0)
-	count = structure.Count
-	return
+	return anonymous.GetProjectsCountAll()
 }
 
 /* GetProject returns information about a project.
  */
 func GetProject (id uint64) (structure ProjectResponse, err error) {
-	err = RestRequest (
-		&structure, "/projects/" + strconv.FormatUint(id, 10),
-		0, 0)
-	return
+	return anonymous.GetProject(id)
 }
 
 /* GetProjectRemixes returns the remixes of a project.
@@ -101,20 +144,13 @@ func GetProjectRemixes (
 	structure []ProjectResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/projects/" + strconv.FormatUint(id, 10) + "/remixes",
-		limit, offset)
-	return
+	return anonymous.GetProjectRemixes(id, limit, offset)
 }
 
 /* GetStudio returns information about a studio.
  */
 func GetStudio (id uint64) (structure StudioResponse, err error) {
-	err = RestRequest (
-		&structure, "/studios/" + strconv.FormatUint(id, 10),
-		0, 0)
-	return
+	return anonymous.GetStudio(id)
 }
 
 /* GetStudioProjects returns a list of all projects in a studio.
@@ -127,11 +163,7 @@ func GetStudioProjects (
 	structure StudioProjectsResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) + "/projects",
-		limit, offset)
-	return
+	return anonymous.GetStudioProjects(id, limit, offset)
 }
 
 /* GetStudioManagers returns a list of all managers of a studio.
@@ -144,11 +176,7 @@ func GetStudioManagers (
 	structure []UserResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) + "/managers",
-		limit, offset)
-	return
+	return anonymous.GetStudioManagers(id, limit, offset)
 }
 
 /* GetStudioCurators returns a list of all curators of a studio.
@@ -161,11 +189,7 @@ func GetStudioCurators (
 	structure []UserResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) + "/curators",
-		limit, offset)
-	return
+	return anonymous.GetStudioCurators(id, limit, offset)
 }
 
 /* GetStudioActivity returns a list of all recent activityin s studio. If since
@@ -180,14 +204,7 @@ func GetStudioActivity (
 	structure StudioActivityResponse,
 	err error,
 ) {
-	url := "/studios/" + strconv.FormatUint(id, 10) + "/activity"
-
-	if since != "" {
-		since = "dateLimit=" + since
-	}
-	
-	err = RestRequestWithQueryString(&structure, url, limit, 0, since)
-	return
+	return anonymous.GetStudioActivity(id, since, limit)
 }
 
 /* GetStudioComments returns a list of all comments on a studio.
@@ -200,11 +217,7 @@ func GetStudioComments (
 	structure []CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) + "/comments",
-		limit, offset)
-	return
+	return anonymous.GetStudioComments(id, limit, offset)
 }
 
 /* GetStudioComment returns a comment on a studio.
@@ -216,12 +229,7 @@ func GetStudioComment (
 	structure CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) +
-		"/comments/" + strconv.FormatUint(commentID, 10),
-		0, 0)
-	return
+	return anonymous.GetStudioComment(id, commentID)
 }
 
 /* GetStudioCommentReplies returns the replies for a comment on a studio.
@@ -235,26 +243,19 @@ func GetStudioCommentReplies (
 	structure []CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure,
-		"/studios/" + strconv.FormatUint(id, 10) +
-		"/comments/" + strconv.FormatUint(commentID, 10) + "/replies",
-		limit, offset)
-	return
+	return anonymous.GetStudioCommentReplies(id, commentID, limit, offset)
 }
 
 /* GetFeatured returns information about front paged projects.
  */
 func GetFeatured () (structure FeaturedResponse, err error) {
-	err = RestRequest(&structure, "/proxy/featured", 0, 0)
-	return
+	return anonymous.GetFeatured()
 }
 
 /* GetUser returns information about a user.
  */
 func GetUser (name string) (structure UserResponse, err error) {
-	err = RestRequest(&structure, "/users/" + name, 0, 0)
-	return
+	return anonymous.GetUser(name)
 }
 
 /* GetUserFavorites returns all projects favorited by a user.
@@ -267,10 +268,7 @@ func GetUserFavorites (
 	structure []ProjectResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/favorites",
-		limit, offset)
-	return
+	return anonymous.GetUserFavorites(name, limit, offset)
 }
 
 /* GetUserFollowers returns all followers of a user.
@@ -283,10 +281,7 @@ func GetUserFollowers (
 	structure []UserResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/followers",
-		limit, offset)
-	return
+	return anonymous.GetUserFollowers(name, limit, offset)
 }
 
 /* GetUserFollowing returns all users a user is following.
@@ -299,20 +294,13 @@ func GetUserFollowing (
 	structure []UserResponse,
 	err error,
 ) {
-	err = RestRequest (&structure, "/users/" + name + "/followers",
-		limit, offset)
-	return
+	return anonymous.GetUserFollowing(name, limit, offset)
 }
 
 /* GetUserMessageCount returns the amount of messages a user has.
  */
 func GetUserMessageCount (name string) (count uint64, err error) {
-	structure := CountResponse { }
-	err = RestRequest (
-		&structure, "/users/" + name + "/messages/count",
-		0, 0)
-	count = structure.Count
-	return
+	return anonymous.GetUserMessageCount(name)
 }
 
 /* GetUserProjects returns all projects made by a user.
@@ -325,10 +313,7 @@ func GetUserProjects (
 	structure []ProjectResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects",
-		limit, offset)
-	return
+	return anonymous.GetUserProjects(name, limit, offset)
 }
 
 /* GetUserProject returns a specific project made by a user.
@@ -340,11 +325,7 @@ func GetUserProject (
 	structure ProjectResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects/" +
-		strconv.FormatUint(id, 10),
-		0, 0)
-	return
+	return anonymous.GetUserProject(name, id)
 }
 
 /* GetUserProject returns a specific project made by a user.
@@ -358,11 +339,7 @@ func GetUserProjectStudios (
 	structure []StudioResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects/" +
-		strconv.FormatUint(id, 10) + "/studios",
-		limit, offset)
-	return
+	return anonymous.GetUserProjectStudios(name, id, limit, offset)
 }
 
 /* GetUserProjectComments returns a list of all comments on a user's project.
@@ -376,11 +353,7 @@ func GetUserProjectComments (
 	structure []CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects/" +
-		strconv.FormatUint(id, 10) + "/comments",
-		limit, offset)
-	return
+	return anonymous.GetUserProjectComments(name, id, limit, offset)
 }
 
 /* GetUserProjectComment returns information about a specific comment on a
@@ -396,12 +369,9 @@ func GetUserProjectComment (
 	structure CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects/" +
-		strconv.FormatUint(id, 10) + "/comments/" +
-		strconv.FormatUint(commentid, 10),
+	return anonymous.GetUserProjectComment (
+		name, id, commentid,
 		limit, offset)
-	return
 }
 
 /* GetUserProjectCommentReplies returns replies to a specific comment on a
@@ -417,12 +387,9 @@ func GetUserProjectCommentReplies (
 	structure []CommentResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/projects/" +
-		strconv.FormatUint(id, 10) + "/comments/" +
-		strconv.FormatUint(commentid, 10) + "/replies",
+	return anonymous.GetUserProjectCommentReplies (
+		name, id, commentid,
 		limit, offset)
-	return
 }
 
 /* GetUserStudiosCurate returns the studios that the user is a curator of.
@@ -435,10 +402,7 @@ func GetUserStudiosCurate (
 	structure []StudioResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/users/" + name + "/studios/curate",
-		limit, offset)
-	return
+	return anonymous.GetUserStudiosCurate(name, limit, offset)
 }
 
 /* GetAccountsCheckUsername checks whether a new account with the specified
@@ -450,29 +414,7 @@ func GetAccountsCheckUsername (
 	structure AccountsCheckUsernameResponse,
 	err error,
 ) {
-	err = RestRequest (
-		&structure, "/accounts/checkusername/" + name,
-		0, 0)
-	return
-}
-
-func getSearchResults [T any] (
-	where     string,
-	query     string,
-	mode      string,
-	language  string,
-	limit     int,
-	offset    int,
-	structure *T,
-) (
-	err error,
-) {
-	queryString := ""
-	if query     != "" { queryString += "q="        + query    + "&" }
-	if mode      != "" { queryString += "mode="     + mode     + "&" }
-	if language  != "" { queryString += "language=" + language + "&" }
-	
-	return RestRequestWithQueryString(&structure, where, limit, 0, queryString)
+	return anonymous.GetAccountsCheckUsername(name)
 }
 
 /* GetExploreProjects returns explore results for the projects tab.
@@ -487,11 +429,9 @@ func GetExploreProjects (
 	structure []ProjectResponse,
 	err error,
 ) {
-	err = getSearchResults (
-		"/explore/projects",
+	return anonymous.GetExploreProjects (
 		query, mode, language,
-		limit, offset, &structure)
-	return
+		limit, offset)
 }
 
 /* GetExploreStudios returns explore results for the studios tab.
@@ -506,11 +446,9 @@ func GetExploreStudios (
 	structure []StudioResponse,
 	err error,
 ) {
-	err = getSearchResults (
-		"/explore/studios",
+	return anonymous.GetExploreStudios (
 		query, mode, language,
-		limit, offset, &structure)
-	return
+		limit, offset)
 }
 
 /* GetSearchProjects returns search results for the projects tab.
@@ -525,11 +463,9 @@ func GetSearchProjects (
 	structure []ProjectResponse,
 	err error,
 ) {
-	err = getSearchResults (
-		"/search/projects",
+	return anonymous.GetSearchProjects (
 		query, mode, language,
-		limit, offset, &structure)
-	return
+		limit, offset)
 }
 
 /* GetSearchStudios returns search results for the studios tab.
@@ -544,9 +480,7 @@ func GetSearchStudios (
 	structure []StudioResponse,
 	err error,
 ) {
-	err = getSearchResults (
-		"/search/studios",
+	return anonymous.GetSearchStudios (
 		query, mode, language,
-		limit, offset, &structure)
-	return
+		limit, offset)
 }
@@ -0,0 +1,95 @@
+package sb2
+
+import "io"
+import "fmt"
+import "path"
+import "strconv"
+import "strings"
+import "archive/zip"
+
+/* Archive represents an sb2 file, which is a zip archive containing a
+ * project.json file and the costumes and sounds of the project. Each asset is
+ * named after its ID, followed by its file extension.
+ */
+type Archive struct {
+	Project *Project
+	assets  map[string] *zip.File
+}
+
+/* Open reads an sb2 file and parses its project.json file. Assets are not read
+ * until they are requested.
+ */
+func Open (reader io.ReaderAt, size int64) (archive *Archive, err error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sb2 file: %v", err)
+	}
+
+	archive = &Archive { assets: make(map[string] *zip.File) }
+	var projectFile *zip.File
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() { continue }
+		name := path.Base(file.Name)
+		if name == "project.json" {
+			projectFile = file
+		} else {
+			archive.assets[name] = file
+		}
+	}
+
+	if projectFile == nil {
+		return nil, fmt.Errorf("cannot open sb2 file: no project.json")
+	}
+	data, err := readFile(projectFile)
+	if err != nil { return nil, err }
+	archive.Project, err = Parse(data)
+	if err != nil { return nil, err }
+	return
+}
+
+/* Asset reads the asset with the specified name from the archive.
+ */
+func (archive *Archive) Asset (name string) (data []byte, err error) {
+	file, ok := archive.assets[name]
+	if !ok {
+		return nil, fmt.Errorf("asset %s is not in sb2 file", name)
+	}
+	return readFile(file)
+}
+
+/* AssetMap reads the costumes and sounds of the project from the archive, and
+ * returns them in a map keyed by the MD5 based names that they have on the
+ * asset server. The result can be passed to Convert.
+ */
+func (archive *Archive) AssetMap () (assets map[string] []byte, err error) {
+	assets = make(map[string] []byte)
+	read := func (id int, md5 string) error {
+		name := strconv.Itoa(id) + path.Ext(md5)
+		if _, ok := archive.assets[name]; !ok { return nil }
+		data, err := archive.Asset(name)
+		if err != nil { return err }
+		assets[strings.ToLower(md5)] = data
+		return nil
+	}
+
+	for _, object := range archive.Project.Objects() {
+		for _, costume := range object.Costumes {
+			err = read(costume.BaseLayerID, costume.BaseLayerMD5)
+			if err != nil { return nil, err }
+		}
+		for _, sound := range object.Sounds {
+			err = read(sound.SoundID, sound.MD5)
+			if err != nil { return nil, err }
+		}
+	}
+	return
+}
+
+/* readFile reads the entire contents of a file inside of a zip archive.
+ */
+func readFile (file *zip.File) (data []byte, err error) {
+	reader, err := file.Open()
+	if err != nil { return }
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
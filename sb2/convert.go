@@ -0,0 +1,315 @@
+package sb2
+
+import "fmt"
+import "path"
+import "strings"
+import "encoding/json"
+import "github.com/scapi3/sb3"
+
+// rotationStyles maps Scratch 2 rotation styles to their Scratch 3 names.
+var rotationStyles = map[string] string {
+	"normal":    "all around",
+	"leftRight": "left-right",
+	"none":      "don't rotate",
+}
+
+// coreCategories holds the block categories that do not belong to an
+// extension.
+var coreCategories = map[string] bool {
+	"motion":     true,
+	"looks":      true,
+	"sound":      true,
+	"event":      true,
+	"control":    true,
+	"sensing":    true,
+	"operator":   true,
+	"data":       true,
+	"procedures": true,
+	"argument":   true,
+}
+
+/* Report describes the parts of a project that could not be converted.
+ * Unconverted counts the blocks that were left out of the converted project by
+ * their Scratch 2 opcode, and Warnings describes anything else that was lost
+ * or changed.
+ */
+type Report struct {
+	Unconverted map[string] int
+	Warnings    []string
+}
+
+/* Empty returns whether the whole project was converted.
+ */
+func (report Report) Empty () (empty bool) {
+	return len(report.Unconverted) == 0 && len(report.Warnings) == 0
+}
+
+/* String lists the unconverted opcodes, followed by the warnings, one per
+ * line.
+ */
+func (report Report) String () (output string) {
+	builder := strings.Builder { }
+	for _, opcode := range sb3.SortedKeys(report.Unconverted) {
+		count := report.Unconverted[opcode]
+		fmt.Fprintf(&builder, "unconverted block %s", opcode)
+		if count > 1 { fmt.Fprintf(&builder, " (%d times)", count) }
+		builder.WriteString("\n")
+	}
+	for _, warning := range report.Warnings {
+		builder.WriteString(warning + "\n")
+	}
+	return builder.String()
+}
+
+/* converter holds the state of a conversion that is shared between targets.
+ */
+type converter struct {
+	project    *sb3.Project
+	stage      *sb3.Target
+	assets     map[string] []byte
+	converted  map[string] []byte
+	report     *Report
+	extensions map[string] bool
+	nextID     int
+}
+
+/* Convert converts a Scratch 2 project into a Scratch 3 project. Assets holds
+ * the contents of the project's costumes and sounds keyed by their MD5 based
+ * names, as returned by Archive.AssetMap, and may be nil if only the
+ * project.json file is needed. The converted assets are returned keyed by
+ * their Scratch 3 file names.
+ *
+ * Blocks that have no Scratch 3 equivalent are left out and counted in the
+ * report. Monitors and comments are not converted.
+ */
+func Convert (
+	project *Project,
+	assets  map[string] []byte,
+) (
+	converted       *sb3.Project,
+	convertedAssets map[string] []byte,
+	report          Report,
+	err             error,
+) {
+	if project == nil || project.Stage == nil {
+		return nil, nil, report, fmt.Errorf("cannot convert empty sb2 project")
+	}
+
+	state := &converter {
+		project: &sb3.Project {
+			Monitors: []*sb3.Monitor { },
+			Meta: sb3.Meta {
+				Semver: "3.0.0",
+				VM:     "0.2.0",
+				Agent:  "scapi3",
+			},
+		},
+		assets:     assets,
+		converted:  map[string] []byte { },
+		report:     &Report { Unconverted: map[string] int { } },
+		extensions: map[string] bool { },
+	}
+
+	// all targets are created before any scripts are converted, so that
+	// scripts can refer to global variables and to other sprites
+	state.stage = state.convertObject(project.Stage, true)
+	state.stage.Name = "Stage"
+	targets := []*sb3.Target { state.stage }
+	for index, sprite := range project.Sprites {
+		target := state.convertObject(sprite, false)
+		target.LayerOrder = index + 1
+		targets = append(targets, target)
+	}
+	state.project.Targets = targets
+
+	for index, object := range project.Objects() {
+		state.convertScripts(object, targets[index])
+	}
+
+	state.project.Extensions = sb3.SortedKeys(state.extensions)
+	return state.project, state.converted, *state.report, nil
+}
+
+/* warn adds a warning to the report.
+ */
+func (state *converter) warn (format string, args ...any) {
+	state.report.Warnings = append (
+		state.report.Warnings,
+		fmt.Sprintf(format, args...))
+}
+
+/* newID returns an ID that has not been used in the project yet.
+ */
+func (state *converter) newID () (id string) {
+	state.nextID ++
+	return fmt.Sprintf("id%d", state.nextID)
+}
+
+/* convertObject converts the stage or a sprite, along with its variables,
+ * lists, costumes, and sounds.
+ */
+func (state *converter) convertObject (
+	object *Object,
+	stage  bool,
+) (
+	target *sb3.Target,
+) {
+	target = &sb3.Target {
+		IsStage:        stage,
+		Name:           object.Name,
+		Variables:      map[string] *sb3.Variable { },
+		Lists:          map[string] *sb3.List     { },
+		Broadcasts:     map[string] string        { },
+		Blocks:         map[string] *sb3.Block    { },
+		Comments:       map[string] *sb3.Comment  { },
+		CurrentCostume: object.CurrentCostumeIndex,
+		Volume:         100,
+	}
+
+	if stage {
+		target.Tempo             = object.TempoBPM
+		target.VideoTransparency = 50
+		target.VideoState        = "on"
+		if target.Tempo == 0 { target.Tempo = 60 }
+	} else {
+		target.Visible       = object.Visible
+		target.X             = object.ScratchX
+		target.Y             = object.ScratchY
+		target.Size          = object.Scale * 100
+		target.Direction     = object.Direction
+		target.Draggable     = object.IsDraggable
+		target.RotationStyle = rotationStyles[object.RotationStyle]
+		if target.Size == 0 { target.Size = 100 }
+		if target.RotationStyle == "" { target.RotationStyle = "all around" }
+	}
+
+	for _, variable := range object.Variables {
+		id := state.newID()
+		target.Variables[id] = &sb3.Variable {
+			ID:      id,
+			Name:    variable.Name,
+			Value:   literal(variable.Value),
+			IsCloud: variable.IsPersistent,
+		}
+	}
+	for _, list := range object.Lists {
+		id := state.newID()
+		values := make([]any, len(list.Contents))
+		for index, value := range list.Contents {
+			values[index] = literal(value)
+		}
+		target.Lists[id] = &sb3.List { ID: id, Name: list.Name, Values: values }
+	}
+
+	for _, costume := range object.Costumes {
+		target.Costumes = append(target.Costumes, state.convertCostume (
+			costume, object.Name))
+	}
+	for _, sound := range object.Sounds {
+		target.Sounds = append(target.Sounds, state.convertSound (
+			sound, object.Name))
+	}
+	if target.CurrentCostume >= len(target.Costumes) { target.CurrentCostume = 0 }
+	return
+}
+
+/* convertCostume converts a costume, and copies its image into the converted
+ * assets.
+ */
+func (state *converter) convertCostume (
+	costume Costume,
+	owner   string,
+) (
+	converted *sb3.Costume,
+) {
+	if costume.TextLayerID != nil {
+		state.warn (
+			"costume %q of %s has a text layer, which was not converted",
+			costume.Name, owner)
+	}
+
+	resolution := costume.BitmapResolution
+	if resolution == 0 { resolution = 1 }
+	id, format, fileName := state.convertAsset (
+		costume.BaseLayerMD5, "costume", costume.Name, owner)
+	return &sb3.Costume {
+		AssetID:          id,
+		Name:             costume.Name,
+		BitmapResolution: resolution,
+		MD5Ext:           fileName,
+		DataFormat:       format,
+		RotationCenterX:  costume.RotationCenterX,
+		RotationCenterY:  costume.RotationCenterY,
+	}
+}
+
+/* convertSound converts a sound, and copies its audio into the converted
+ * assets.
+ */
+func (state *converter) convertSound (
+	sound Sound,
+	owner string,
+) (
+	converted *sb3.Sound,
+) {
+	id, format, fileName := state.convertAsset(sound.MD5, "sound", sound.Name, owner)
+	return &sb3.Sound {
+		AssetID:     id,
+		Name:        sound.Name,
+		DataFormat:  format,
+		Format:      sound.Format,
+		Rate:        sound.Rate,
+		SampleCount: sound.SampleCount,
+		MD5Ext:      fileName,
+	}
+}
+
+/* convertAsset finds the data of an asset from its Scratch 2 name, and returns
+ * its Scratch 3 asset ID, format, and file name. If the data is missing, the
+ * name is kept as it is, and a warning is added unless no assets were given
+ * at all.
+ */
+func (state *converter) convertAsset (
+	md5   string,
+	kind  string,
+	name  string,
+	owner string,
+) (
+	id       string,
+	format   string,
+	fileName string,
+) {
+	md5      = strings.ToLower(md5)
+	format   = strings.TrimPrefix(path.Ext(md5), ".")
+	id       = strings.TrimSuffix(md5, path.Ext(md5))
+	fileName = md5
+
+	data, ok := state.assets[md5]
+	if !ok {
+		if state.assets != nil {
+			state.warn("%s %q of %s has no data", kind, name, owner)
+		}
+		return
+	}
+	id       = sb3.AssetID(data)
+	fileName = sb3.AssetName(data, format)
+	state.converted[fileName] = data
+	return
+}
+
+/* literal converts a value decoded from a Scratch 2 project into one that can
+ * be stored in a Scratch 3 project. Numbers are decoded as json.Number, and
+ * are converted to float64 if possible.
+ */
+func literal (value any) (converted any) {
+	switch value := value.(type) {
+	case json.Number:
+		number, err := value.Float64()
+		if err != nil { return value.String() }
+		return number
+	case nil:
+		return ""
+	default:
+		return value
+	}
+}
@@ -0,0 +1,230 @@
+package sb2
+
+import "os"
+import "strings"
+import "testing"
+import "github.com/scapi3/sb3"
+
+/* convertFixture parses and converts a project in the testdata directory.
+ */
+func convertFixture (test *testing.T, name string) (
+	converted *sb3.Project,
+	report    Report,
+) {
+	test.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil { test.Fatal(err) }
+	project, err := Parse(data)
+	if err != nil { test.Fatal(err) }
+	converted, _, report, err = Convert(project, nil)
+	if err != nil { test.Fatal(err) }
+	return
+}
+
+/* stackOpcodes returns the opcodes of a block and the blocks after it.
+ */
+func stackOpcodes (target *sb3.Target, id string) (opcodes []string) {
+	for _, id := range target.Stack(id) {
+		opcodes = append(opcodes, target.Blocks[id].Opcode)
+	}
+	return
+}
+
+/* expectOpcodes checks a list of opcodes.
+ */
+func expectOpcodes (test *testing.T, what string, opcodes []string, expected ...string) {
+	test.Helper()
+	if strings.Join(opcodes, " ") != strings.Join(expected, " ") {
+		test.Fatalf("%s are %v, expected %v", what, opcodes, expected)
+	}
+}
+
+/* inputBlock returns the block in an input, failing if there is none.
+ */
+func inputBlock (test *testing.T, target *sb3.Target, block *sb3.Block, name string) (inner *sb3.Block) {
+	test.Helper()
+	input, ok := block.Inputs[name]
+	if !ok { test.Fatalf("%s has no input %s", block.Opcode, name) }
+	inner = target.Blocks[input.Block.BlockID]
+	if inner == nil {
+		test.Fatalf("input %s of %s holds %+v, expected a block", name, block.Opcode, input.Block)
+	}
+	if inner.Parent != block.ID {
+		test.Fatalf("block in input %s of %s has parent %q", name, block.Opcode, inner.Parent)
+	}
+	return
+}
+
+func TestConvertScripts (test *testing.T) {
+	project, _ := convertFixture(test, "scripts.json")
+	stage  := project.Stage()
+	sprite := project.Target("Cat")
+	if sprite == nil { test.Fatal("sprite was not converted") }
+
+	scripts := sprite.Scripts()
+	if len(scripts) != 3 {
+		test.Fatalf("sprite has %d scripts, expected 3", len(scripts))
+	}
+	main := sprite.Blocks[scripts[0]]
+	if main.X != 10 || main.Y != 20 {
+		test.Fatalf("first script is at %v, %v", main.X, main.Y)
+	}
+	expectOpcodes (
+		test, "blocks of the first script", stackOpcodes(sprite, main.ID),
+		"event_whenflagclicked", "motion_movesteps", "data_setvariableto",
+		"control_repeat", "procedures_call", "data_addtolist",
+		"event_broadcast")
+	stack := sprite.Stack(main.ID)
+
+	// literal values become shadows of the right type
+	steps := sprite.Blocks[stack[1]].Inputs["STEPS"]
+	if steps.ShadowType != sb3.ShadowTypeSame ||
+		steps.Block.Primitive == nil ||
+		steps.Block.Primitive.Type != sb3.PrimitiveTypeMathNumber ||
+		steps.Block.Primitive.Value != 10.0 {
+		test.Fatalf("STEPS input is %+v", steps)
+	}
+
+	// variables refer to the stage's variables by ID, and reporters cover
+	// up a shadow
+	set := sprite.Blocks[stack[2]]
+	scoreID := ""
+	for id, variable := range stage.Variables {
+		if variable.Name == "score" { scoreID = id }
+	}
+	if set.Fields["VARIABLE"].ID != scoreID || set.Fields["VARIABLE"].Value != "score" {
+		test.Fatalf("VARIABLE field is %+v, expected score with ID %s", set.Fields["VARIABLE"], scoreID)
+	}
+	if set.Inputs["VALUE"].ShadowType != sb3.ShadowTypeObscured {
+		test.Fatalf("VALUE input has shadow type %d", set.Inputs["VALUE"].ShadowType)
+	}
+	add := inputBlock(test, sprite, set, "VALUE")
+	if add.Opcode != "operator_add" {
+		test.Fatalf("VALUE input holds %s", add.Opcode)
+	}
+	reference := add.Inputs["NUM1"].Block.Primitive
+	if reference == nil ||
+		reference.Type != sb3.PrimitiveTypeVariable ||
+		reference.ID != scoreID {
+		test.Fatalf("NUM1 input is %+v, expected a reference to score", add.Inputs["NUM1"])
+	}
+
+	// the unconverted drum block is left out of the substack
+	repeat := sprite.Blocks[stack[3]]
+	turn   := inputBlock(test, sprite, repeat, "SUBSTACK")
+	expectOpcodes(test, "blocks in the loop", stackOpcodes(sprite, turn.ID), "motion_turnright")
+
+	list := sprite.Blocks[stack[5]]
+	if list.Fields["LIST"].Value != "items" || stage.Lists[list.Fields["LIST"].ID] == nil {
+		test.Fatalf("LIST field is %+v", list.Fields["LIST"])
+	}
+	broadcast := sprite.Blocks[stack[6]].Inputs["BROADCAST_INPUT"].Block.Primitive
+	if broadcast == nil || stage.Broadcasts[broadcast.ID] != "go" {
+		test.Fatalf("broadcast input refers to %+v", broadcast)
+	}
+
+	err := project.Validate()
+	if err != nil { test.Fatal(err) }
+}
+
+func TestConvertProcedures (test *testing.T) {
+	project, _ := convertFixture(test, "scripts.json")
+	sprite := project.Target("Cat")
+	scripts := sprite.Scripts()
+
+	definition := sprite.Blocks[scripts[1]]
+	expectOpcodes (
+		test, "blocks of the definition", stackOpcodes(sprite, definition.ID),
+		"procedures_definition", "motion_changeyby")
+	prototype := inputBlock(test, sprite, definition, "custom_block")
+	if prototype.Opcode != "procedures_prototype" || !prototype.Shadow {
+		test.Fatalf("definition holds %s", prototype.Opcode)
+	}
+
+	mutation := prototype.Mutation
+	if mutation == nil { test.Fatal("prototype has no mutation") }
+	if mutation.ProcCode != "jump %s times %b" {
+		test.Fatalf("proccode is %q", mutation.ProcCode)
+	}
+	if mutation.ArgumentNames != `["count","bounce"]` ||
+		mutation.ArgumentDefaults != `["1","false"]` ||
+		!mutation.IsWarp() {
+		test.Fatalf("prototype mutation is %+v", mutation)
+	}
+	ids, err := mutation.ArgumentIDList()
+	if err != nil { test.Fatal(err) }
+	if len(ids) != 2 { test.Fatalf("prototype has argument IDs %v", ids) }
+	for index, opcode := range []string {
+		"argument_reporter_string_number",
+		"argument_reporter_boolean",
+	} {
+		reporter := inputBlock(test, sprite, prototype, ids[index])
+		if reporter.Opcode != opcode {
+			test.Fatalf("argument %d is %s, expected %s", index, reporter.Opcode, opcode)
+		}
+	}
+
+	// arguments are read with reporters named after them
+	change := sprite.Blocks[definition.Next]
+	argument := inputBlock(test, sprite, change, "DY")
+	if argument.Opcode != "argument_reporter_string_number" ||
+		argument.Fields["VALUE"].Value != "count" {
+		test.Fatalf("DY input holds %s %+v", argument.Opcode, argument.Fields)
+	}
+
+	// calls use the same argument IDs as the prototype
+	call := sprite.Blocks[sprite.Stack(scripts[0])[4]]
+	if call.Mutation == nil ||
+		call.Mutation.ProcCode != mutation.ProcCode ||
+		call.Mutation.ArgumentIDs != mutation.ArgumentIDs {
+		test.Fatalf("call mutation is %+v", call.Mutation)
+	}
+	count := call.Inputs[ids[0]].Block.Primitive
+	if count == nil || count.Type != sb3.PrimitiveTypeText || count.Value != 5.0 {
+		test.Fatalf("first argument is %+v", call.Inputs[ids[0]])
+	}
+	if call.Inputs[ids[1]].ShadowType != sb3.ShadowTypeNone {
+		test.Fatalf("boolean argument has shadow type %d", call.Inputs[ids[1]].ShadowType)
+	}
+	touching := inputBlock(test, sprite, call, ids[1])
+	if touching.Opcode != "sensing_touchingobject" {
+		test.Fatalf("boolean argument holds %s", touching.Opcode)
+	}
+	menu := inputBlock(test, sprite, touching, "TOUCHINGOBJECTMENU")
+	if menu.Fields["TOUCHINGOBJECTMENU"].Value != "_edge_" {
+		test.Fatalf("touching menu is %+v", menu.Fields)
+	}
+}
+
+func TestConvertReport (test *testing.T) {
+	project, report := convertFixture(test, "scripts.json")
+	if report.Empty() { test.Fatal("report is empty") }
+	if len(report.Unconverted) != 1 || report.Unconverted["playDrum"] != 2 {
+		test.Fatalf("unconverted blocks are %v, expected playDrum twice", report.Unconverted)
+	}
+	if len(project.Extensions) != 0 {
+		test.Fatalf("extensions are %v, expected none", project.Extensions)
+	}
+
+	expected := []string {
+		`call to undefined custom block "missing %s" in Cat was removed`,
+		`variable "ghost" used by Cat does not exist, and was added to the stage`,
+	}
+	if strings.Join(report.Warnings, "\n") != strings.Join(expected, "\n") {
+		test.Fatalf("warnings are %q, expected %q", report.Warnings, expected)
+	}
+	if report.String() !=
+		"unconverted block playDrum (2 times)\n" +
+		strings.Join(expected, "\n") + "\n" {
+		test.Fatalf("report is %q", report.String())
+	}
+
+	// the script that started with the missing call begins at the block
+	// after it
+	sprite := project.Target("Cat")
+	last := sprite.Scripts()[2]
+	expectOpcodes(test, "blocks of the last script", stackOpcodes(sprite, last), "data_setvariableto")
+	if !sprite.Blocks[last].TopLevel || sprite.Blocks[last].Parent != "" {
+		test.Fatalf("last script starts with %+v", sprite.Blocks[last])
+	}
+}
@@ -0,0 +1,151 @@
+/* Package sb2 parses Scratch 2 projects, and converts them to Scratch 3
+ * projects.
+ */
+package sb2
+
+import "fmt"
+import "bytes"
+import "encoding/json"
+
+/* Project represents the contents of a Scratch 2 project.json file. The stage
+ * is the top level object, and the sprites are its children.
+ */
+type Project struct {
+	Stage   *Object
+	Sprites []*Object
+}
+
+/* Object is the stage or a sprite. Some fields only apply to the stage, and
+ * some only apply to sprites.
+ */
+type Object struct {
+	Name                string     `json:"objName"`
+	Variables           []Variable `json:"variables"`
+	Lists               []List     `json:"lists"`
+	Scripts             []Script   `json:"scripts"`
+	Sounds              []Sound    `json:"sounds"`
+	Costumes            []Costume  `json:"costumes"`
+	CurrentCostumeIndex int        `json:"currentCostumeIndex"`
+
+	// stage only
+	TempoBPM float64            `json:"tempoBPM"`
+	Children []json.RawMessage  `json:"children"`
+	Info     map[string] any    `json:"info"`
+
+	// sprites only
+	ScratchX       float64 `json:"scratchX"`
+	ScratchY       float64 `json:"scratchY"`
+	Scale          float64 `json:"scale"`
+	Direction      float64 `json:"direction"`
+	RotationStyle  string  `json:"rotationStyle"`
+	IsDraggable    bool    `json:"isDraggable"`
+	IndexInLibrary int     `json:"indexInLibrary"`
+	Visible        bool    `json:"visible"`
+}
+
+/* Variable represents a variable. Persistent variables are cloud variables.
+ */
+type Variable struct {
+	Name         string `json:"name"`
+	Value        any    `json:"value"`
+	IsPersistent bool   `json:"isPersistent"`
+}
+
+/* List represents a list.
+ */
+type List struct {
+	Name         string `json:"listName"`
+	Contents     []any  `json:"contents"`
+	IsPersistent bool   `json:"isPersistent"`
+}
+
+/* Costume represents a costume of a sprite or a backdrop of the stage. The
+ * image is stored in archives as a file named after BaseLayerID, and on the
+ * asset server as a file named BaseLayerMD5.
+ */
+type Costume struct {
+	Name             string  `json:"costumeName"`
+	BaseLayerID      int     `json:"baseLayerID"`
+	BaseLayerMD5     string  `json:"baseLayerMD5"`
+	BitmapResolution int     `json:"bitmapResolution"`
+	RotationCenterX  float64 `json:"rotationCenterX"`
+	RotationCenterY  float64 `json:"rotationCenterY"`
+	TextLayerID      *int    `json:"textLayerID"`
+}
+
+/* Sound represents a sound of a sprite or the stage. The audio is stored in
+ * archives as a file named after SoundID, and on the asset server as a file
+ * named MD5.
+ */
+type Sound struct {
+	Name        string `json:"soundName"`
+	SoundID     int    `json:"soundID"`
+	MD5         string `json:"md5"`
+	SampleCount int    `json:"sampleCount"`
+	Rate        int    `json:"rate"`
+	Format      string `json:"format"`
+}
+
+/* Script is a stack of blocks in the code area. Each block is an array whose
+ * first element is the block's opcode, followed by its arguments. Arguments
+ * are either values, blocks, or lists of blocks for C blocks. Numbers are
+ * stored as json.Number.
+ */
+type Script struct {
+	X      float64
+	Y      float64
+	Blocks []any
+}
+
+/* UnmarshalJSON decodes a script from its array form.
+ */
+func (script *Script) UnmarshalJSON (data []byte) (err error) {
+	var array []json.RawMessage
+	err = json.Unmarshal(data, &array)
+	if err != nil { return }
+	if len(array) < 3 {
+		return fmt.Errorf("script has %d elements, expected 3", len(array))
+	}
+
+	*script = Script { }
+	err = json.Unmarshal(array[0], &script.X)
+	if err != nil { return }
+	err = json.Unmarshal(array[1], &script.Y)
+	if err != nil { return }
+
+	decoder := json.NewDecoder(bytes.NewReader(array[2]))
+	decoder.UseNumber()
+	return decoder.Decode(&script.Blocks)
+}
+
+/* Parse parses a Scratch 2 project.json file. Watchers, which are stored among
+ * the sprites, are skipped.
+ */
+func Parse (data []byte) (project *Project, err error) {
+	project = &Project { Stage: &Object { } }
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(project.Stage)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse sb2 project: %v", err)
+	}
+
+	for _, child := range project.Stage.Children {
+		decoder := json.NewDecoder(bytes.NewReader(child))
+		decoder.UseNumber()
+		sprite := &Object { }
+		err = decoder.Decode(sprite)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse sb2 project: %v", err)
+		}
+		if sprite.Name == "" { continue }
+		project.Sprites = append(project.Sprites, sprite)
+	}
+	return
+}
+
+/* Objects returns the stage followed by the sprites.
+ */
+func (project *Project) Objects () (objects []*Object) {
+	return append([]*Object { project.Stage }, project.Sprites...)
+}
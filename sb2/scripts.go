@@ -0,0 +1,579 @@
+package sb2
+
+import "fmt"
+import "strings"
+import "encoding/json"
+import "github.com/scapi3/sb3"
+
+/* procedure is a custom block defined by a Scratch 2 sprite. Kinds holds the
+ * type of each argument, which is either 's' or 'b'.
+ */
+type procedure struct {
+	procCode string
+	ids      []string
+	names    []string
+	defaults []string
+	kinds    []byte
+	warp     bool
+}
+
+/* scriptConverter converts the scripts of one sprite, or of the stage.
+ */
+type scriptConverter struct {
+	*converter
+	target     *sb3.Target
+	procedures map[string] *procedure
+}
+
+/* convertScripts converts the scripts of an object into blocks of the target.
+ * Custom blocks are collected first, so that they can be called from scripts
+ * that come before their definitions.
+ */
+func (state *converter) convertScripts (object *Object, target *sb3.Target) {
+	scripts := &scriptConverter {
+		converter:  state,
+		target:     target,
+		procedures: map[string] *procedure { },
+	}
+
+	for _, script := range object.Scripts {
+		if len(script.Blocks) == 0 { continue }
+		first, ok := script.Blocks[0].([]any)
+		if !ok || len(first) < 2 || first[0] != "procDef" { continue }
+		scripts.define(first[1:])
+	}
+
+	for _, script := range object.Scripts {
+		id := scripts.stack(script.Blocks, "")
+		if id == "" { continue }
+		block := target.Blocks[id]
+		block.TopLevel = true
+		block.X = script.X
+		block.Y = script.Y
+	}
+}
+
+/* define records a custom block from the arguments of a procDef block, which
+ * are its spec, the names of its arguments, their default values, and whether
+ * it runs without screen refresh.
+ */
+func (scripts *scriptConverter) define (args []any) {
+	spec, _ := args[0].(string)
+	if _, exists := scripts.procedures[spec]; exists { return }
+
+	defined := &procedure {
+		procCode: strings.ReplaceAll(spec, "%n", "%s"),
+	}
+	for index := 0; index + 1 < len(spec); index ++ {
+		if spec[index] != '%' { continue }
+		kind := spec[index + 1]
+		index ++
+		switch kind {
+		case 's', 'n': kind = 's'
+		case 'b':
+		default:       continue
+		}
+		defined.kinds = append(defined.kinds, kind)
+		defined.ids   = append(defined.ids, scripts.newID())
+	}
+
+	names    := nth(args, 1)
+	defaults := nth(args, 2)
+	nameList,    _ := names.([]any)
+	defaultList, _ := defaults.([]any)
+	for index, kind := range defined.kinds {
+		name := ""
+		if index < len(nameList) { name = fmt.Sprint(literal(nameList[index])) }
+		value := ""
+		if kind == 'b' { value = "false" }
+		if index < len(defaultList) && kind != 'b' {
+			value = fmt.Sprint(literal(defaultList[index]))
+		}
+		defined.names    = append(defined.names, name)
+		defined.defaults = append(defined.defaults, value)
+	}
+	defined.warp, _ = nth(args, 3).(bool)
+	scripts.procedures[spec] = defined
+}
+
+/* stack converts a list of blocks, and returns the ID of the first one. Blocks
+ * that cannot be converted are left out, and the blocks around them are joined
+ * together.
+ */
+func (scripts *scriptConverter) stack (blocks []any, parent string) (first string) {
+	var previous *sb3.Block
+	for _, item := range blocks {
+		block, ok := item.([]any)
+		if !ok { continue }
+		id := scripts.block(block, parent)
+		if id == "" { continue }
+
+		if previous == nil {
+			first = id
+		} else {
+			previous.Next = id
+		}
+		previous = scripts.target.Blocks[id]
+		parent   = id
+	}
+	return
+}
+
+/* block converts a single block along with the blocks inside of it, and
+ * returns its ID. If the block cannot be converted, it is counted in the report
+ * and a blank ID is returned.
+ */
+func (scripts *scriptConverter) block (block []any, parent string) (id string) {
+	if len(block) == 0 { return "" }
+	opcode, _ := block[0].(string)
+	args := block[1:]
+
+	switch opcode {
+	case "procDef":
+		return scripts.definition(args, parent)
+	case "call":
+		return scripts.call(args, parent)
+	case "getParam":
+		name := scripts.fieldText(nth(args, 0), opcode)
+		reporter := "argument_reporter_string_number"
+		if nth(args, 1) == "b" { reporter = "argument_reporter_boolean" }
+		id = scripts.emit(reporter, parent, false)
+		scripts.target.Blocks[id].Fields["VALUE"] = sb3.Field { Value: name }
+		return
+	case "whenClicked":
+		if scripts.target.IsStage {
+			return scripts.emit("event_whenstageclicked", parent, false)
+		}
+		return scripts.emit("event_whenthisspriteclicked", parent, false)
+	case "stopScripts":
+		option := scripts.fieldText(nth(args, 0), opcode)
+		if option == "other scripts in stage" { option = "other scripts in sprite" }
+		id = scripts.emit("control_stop", parent, false)
+		stop := scripts.target.Blocks[id]
+		stop.Fields["STOP_OPTION"] = sb3.Field { Value: option }
+		stop.Mutation = &sb3.Mutation {
+			TagName: "mutation",
+			HasNext: fmt.Sprint(strings.HasPrefix(option, "other")),
+		}
+		return
+	}
+
+	spec, ok := specs[opcode]
+	if !ok {
+		scripts.report.Unconverted[opcode] ++
+		return ""
+	}
+
+	id = scripts.emit(spec.opcode, parent, false)
+	converted := scripts.target.Blocks[id]
+	for name, value := range spec.fields {
+		converted.Fields[name] = sb3.Field { Value: value }
+	}
+	for index, arg := range spec.args {
+		scripts.argument(converted, arg, nth(args, index), opcode)
+	}
+	return
+}
+
+/* emit adds an empty block to the target and returns its ID.
+ */
+func (scripts *scriptConverter) emit (
+	opcode string,
+	parent string,
+	shadow bool,
+) (
+	id string,
+) {
+	id = scripts.newID()
+	scripts.target.Blocks[id] = &sb3.Block {
+		ID:     id,
+		Opcode: opcode,
+		Parent: parent,
+		Inputs: map[string] sb3.Input { },
+		Fields: map[string] sb3.Field { },
+		Shadow: shadow,
+	}
+
+	category, _, found := strings.Cut(opcode, "_")
+	if found && !shadow && !coreCategories[category] {
+		scripts.extensions[category] = true
+	}
+	return
+}
+
+/* argument converts one argument of a Scratch 2 block into an input or field
+ * of the converted block.
+ */
+func (scripts *scriptConverter) argument (
+	block  *sb3.Block,
+	arg    argSpec,
+	value  any,
+	opcode string,
+) {
+	switch arg.kind {
+	case argInput:
+		block.Inputs[arg.name] = scripts.value(value, arg.primitive, block.ID, "")
+	case argColor:
+		block.Inputs[arg.name] = scripts.value (
+			colorValue(value), arg.primitive, block.ID, "#000000")
+	case argMenu:
+		block.Inputs[arg.name] = scripts.menu(value, arg, block.ID)
+	case argBroadcast:
+		block.Inputs[arg.name] = scripts.broadcastInput(value, block.ID)
+
+	case argCondition:
+		nested, ok := value.([]any)
+		if !ok { return }
+		id := scripts.block(nested, block.ID)
+		if id == "" { return }
+		block.Inputs[arg.name] = sb3.Input {
+			ShadowType: sb3.ShadowTypeNone,
+			Block:      sb3.InputValue { BlockID: id },
+		}
+	case argSubstack:
+		nested, ok := value.([]any)
+		if !ok { return }
+		id := scripts.stack(nested, block.ID)
+		if id == "" { return }
+		block.Inputs[arg.name] = sb3.Input {
+			ShadowType: sb3.ShadowTypeNone,
+			Block:      sb3.InputValue { BlockID: id },
+		}
+
+	case argField:
+		text := scripts.fieldText(value, opcode)
+		if arg.convert != nil { text = arg.convert(text) }
+		block.Fields[arg.name] = sb3.Field { Value: text }
+	case argVariable:
+		name := scripts.fieldText(value, opcode)
+		block.Fields[arg.name] = sb3.Field {
+			Value: name,
+			ID:    scripts.variable(name),
+		}
+	case argList:
+		name := scripts.fieldText(value, opcode)
+		block.Fields[arg.name] = sb3.Field {
+			Value: name,
+			ID:    scripts.list(name),
+		}
+	case argBroadcastField:
+		name := scripts.fieldText(value, opcode)
+		block.Fields[arg.name] = sb3.Field {
+			Value: name,
+			ID:    scripts.broadcast(name),
+		}
+	}
+}
+
+/* value creates an input that holds a value. Literal values are stored in a
+ * shadow of the specified type, and reporters cover up a shadow that holds
+ * empty. Variable and list reporters are stored in the compact form that
+ * Scratch 3 uses for them.
+ */
+func (scripts *scriptConverter) value (
+	value     any,
+	primitive sb3.PrimitiveType,
+	parent    string,
+	empty     any,
+) (
+	input sb3.Input,
+) {
+	shadow := sb3.InputValue {
+		Primitive: &sb3.Primitive { Type: primitive, Value: empty },
+	}
+
+	nested, ok := value.([]any)
+	if !ok {
+		return sb3.Input {
+			ShadowType: sb3.ShadowTypeSame,
+			Block: sb3.InputValue { Primitive: &sb3.Primitive {
+				Type:  primitive,
+				Value: literal(value),
+			} },
+		}
+	}
+
+	block := sb3.InputValue { Primitive: scripts.reference(nested) }
+	if block.Primitive == nil {
+		block.BlockID = scripts.block(nested, parent)
+		if block.BlockID == "" {
+			return sb3.Input { ShadowType: sb3.ShadowTypeSame, Block: shadow }
+		}
+	}
+	return sb3.Input {
+		ShadowType: sb3.ShadowTypeObscured,
+		Block:      block,
+		Shadow:     shadow,
+	}
+}
+
+/* reference returns the compact form of a variable or list reporter, or nil
+ * if the block is something else.
+ */
+func (scripts *scriptConverter) reference (block []any) (primitive *sb3.Primitive) {
+	if len(block) < 2 { return nil }
+	name, ok := block[1].(string)
+	if !ok { return nil }
+
+	switch block[0] {
+	case "readVariable":
+		return &sb3.Primitive {
+			Type: sb3.PrimitiveTypeVariable,
+			Name: name,
+			ID:   scripts.variable(name),
+		}
+	case "contentsOfList:":
+		return &sb3.Primitive {
+			Type: sb3.PrimitiveTypeList,
+			Name: name,
+			ID:   scripts.list(name),
+		}
+	}
+	return nil
+}
+
+/* menu creates an input that holds a menu. Literal values are selected in a
+ * shadow menu block, and reporters cover up a menu with nothing selected.
+ */
+func (scripts *scriptConverter) menu (
+	value  any,
+	arg    argSpec,
+	parent string,
+) (
+	input sb3.Input,
+) {
+	menu := func (selected any) string {
+		id := scripts.emit(arg.menu, parent, true)
+		scripts.target.Blocks[id].Fields[arg.name] = sb3.Field { Value: selected }
+		return id
+	}
+
+	if nested, ok := value.([]any); ok {
+		id := scripts.block(nested, parent)
+		if id != "" {
+			return sb3.Input {
+				ShadowType: sb3.ShadowTypeObscured,
+				Block:      sb3.InputValue { BlockID: id },
+				Shadow:     sb3.InputValue { BlockID: menu("") },
+			}
+		}
+		value = ""
+	}
+	return sb3.Input {
+		ShadowType: sb3.ShadowTypeSame,
+		Block:      sb3.InputValue { BlockID: menu(literal(value)) },
+	}
+}
+
+/* broadcastInput creates an input that holds a broadcast. A reporter covers up
+ * a shadow, which has to refer to a broadcast that exists.
+ */
+func (scripts *scriptConverter) broadcastInput (
+	value  any,
+	parent string,
+) (
+	input sb3.Input,
+) {
+	primitive := func (name string) sb3.InputValue {
+		return sb3.InputValue { Primitive: &sb3.Primitive {
+			Type: sb3.PrimitiveTypeBroadcast,
+			Name: name,
+			ID:   scripts.broadcast(name),
+		} }
+	}
+
+	nested, ok := value.([]any)
+	if !ok {
+		name := fmt.Sprint(literal(value))
+		return sb3.Input { ShadowType: sb3.ShadowTypeSame, Block: primitive(name) }
+	}
+
+	block := sb3.InputValue { Primitive: scripts.reference(nested) }
+	if block.Primitive == nil {
+		block.BlockID = scripts.block(nested, parent)
+		if block.BlockID == "" {
+			return sb3.Input {
+				ShadowType: sb3.ShadowTypeSame,
+				Block:      primitive("message1"),
+			}
+		}
+	}
+	return sb3.Input {
+		ShadowType: sb3.ShadowTypeObscured,
+		Block:      block,
+		Shadow:     primitive("message1"),
+	}
+}
+
+/* definition converts a procDef block into a custom block definition and its
+ * prototype.
+ */
+func (scripts *scriptConverter) definition (args []any, parent string) (id string) {
+	spec, _ := nth(args, 0).(string)
+	defined, ok := scripts.procedures[spec]
+	if !ok { return "" }
+
+	id = scripts.emit("procedures_definition", parent, false)
+	prototypeID := scripts.emit("procedures_prototype", id, true)
+	prototype := scripts.target.Blocks[prototypeID]
+	prototype.Mutation = defined.mutation(true)
+	scripts.target.Blocks[id].Inputs["custom_block"] = sb3.Input {
+		ShadowType: sb3.ShadowTypeSame,
+		Block:      sb3.InputValue { BlockID: prototypeID },
+	}
+
+	for index, argumentID := range defined.ids {
+		opcode := "argument_reporter_string_number"
+		if defined.kinds[index] == 'b' { opcode = "argument_reporter_boolean" }
+		reporterID := scripts.emit(opcode, prototypeID, true)
+		scripts.target.Blocks[reporterID].Fields["VALUE"] = sb3.Field {
+			Value: defined.names[index],
+		}
+		prototype.Inputs[argumentID] = sb3.Input {
+			ShadowType: sb3.ShadowTypeSame,
+			Block:      sb3.InputValue { BlockID: reporterID },
+		}
+	}
+	return
+}
+
+/* call converts a call to a custom block. Calls to custom blocks that the
+ * sprite does not define are left out.
+ */
+func (scripts *scriptConverter) call (args []any, parent string) (id string) {
+	spec, _ := nth(args, 0).(string)
+	called, ok := scripts.procedures[spec]
+	if !ok {
+		scripts.warn (
+			"call to undefined custom block %q in %s was removed",
+			spec, scripts.target.Name)
+		return ""
+	}
+
+	id = scripts.emit("procedures_call", parent, false)
+	block := scripts.target.Blocks[id]
+	block.Mutation = called.mutation(false)
+	for index, argumentID := range called.ids {
+		value := nth(args, index + 1)
+		if called.kinds[index] == 's' {
+			block.Inputs[argumentID] = scripts.value (
+				value, sb3.PrimitiveTypeText, id, "")
+			continue
+		}
+
+		nested, ok := value.([]any)
+		if !ok { continue }
+		condition := scripts.block(nested, id)
+		if condition == "" { continue }
+		block.Inputs[argumentID] = sb3.Input {
+			ShadowType: sb3.ShadowTypeNone,
+			Block:      sb3.InputValue { BlockID: condition },
+		}
+	}
+	return
+}
+
+/* fieldText converts the value of an argument that becomes a field. Fields
+ * cannot hold blocks in Scratch 3, so blocks are dropped with a warning.
+ */
+func (scripts *scriptConverter) fieldText (value any, opcode string) (text string) {
+	if _, ok := value.([]any); ok {
+		scripts.warn (
+			"block inside of a menu of %s in %s was removed",
+			opcode, scripts.target.Name)
+		return ""
+	}
+	return fmt.Sprint(literal(value))
+}
+
+/* variable returns the ID of the variable with the specified name that the
+ * target can see. Scratch 2 projects sometimes refer to variables that do not
+ * exist, so these are added to the stage.
+ */
+func (scripts *scriptConverter) variable (name string) (id string) {
+	for _, target := range []*sb3.Target { scripts.target, scripts.stage } {
+		for id, variable := range target.Variables {
+			if variable.Name == name { return id }
+		}
+	}
+
+	scripts.warn (
+		"variable %q used by %s does not exist, and was added to the stage",
+		name, scripts.target.Name)
+	id = scripts.newID()
+	scripts.stage.Variables[id] = &sb3.Variable { ID: id, Name: name, Value: 0 }
+	return
+}
+
+/* list returns the ID of the list with the specified name that the target can
+ * see. Lists that do not exist are added to the stage.
+ */
+func (scripts *scriptConverter) list (name string) (id string) {
+	for _, target := range []*sb3.Target { scripts.target, scripts.stage } {
+		for id, list := range target.Lists {
+			if list.Name == name { return id }
+		}
+	}
+
+	scripts.warn (
+		"list %q used by %s does not exist, and was added to the stage",
+		name, scripts.target.Name)
+	id = scripts.newID()
+	scripts.stage.Lists[id] = &sb3.List { ID: id, Name: name, Values: []any { } }
+	return
+}
+
+/* broadcast returns the ID of the broadcast with the specified name. Scratch 2
+ * does not store broadcasts, so they are added to the stage as they are found.
+ */
+func (state *converter) broadcast (name string) (id string) {
+	for id, existing := range state.stage.Broadcasts {
+		if existing == name { return id }
+	}
+	id = state.newID()
+	state.stage.Broadcasts[id] = name
+	return
+}
+
+/* mutation creates the mutation for the custom block's prototype, or for a
+ * call to it.
+ */
+func (defined *procedure) mutation (prototype bool) (mutation *sb3.Mutation) {
+	mutation = &sb3.Mutation {
+		TagName:     "mutation",
+		ProcCode:    defined.procCode,
+		ArgumentIDs: encodeStringList(defined.ids),
+		Warp:        fmt.Sprint(defined.warp),
+	}
+	if !prototype { return }
+	mutation.ArgumentNames    = encodeStringList(defined.names)
+	mutation.ArgumentDefaults = encodeStringList(defined.defaults)
+	return
+}
+
+/* argument returns the argument at the specified index, or nil if the block
+ * has fewer arguments.
+ */
+func nth (args []any, index int) (value any) {
+	if index < len(args) { return args[index] }
+	return nil
+}
+
+/* colorValue converts a color stored as a number into a hex code. Other values
+ * are left as they are.
+ */
+func colorValue (value any) (converted any) {
+	converted = literal(value)
+	number, ok := converted.(float64)
+	if !ok { return }
+	return fmt.Sprintf("#%06x", uint32(int64(number)) & 0xffffff)
+}
+
+/* encodeStringList encodes a list of strings as JSON, which is how Scratch
+ * stores lists inside of mutations.
+ */
+func encodeStringList (list []string) (encoded string) {
+	if list == nil { list = []string { } }
+	data, _ := json.Marshal(list)
+	return string(data)
+}
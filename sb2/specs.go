@@ -0,0 +1,239 @@
+package sb2
+
+import "strings"
+import "github.com/scapi3/sb3"
+
+/* argKind describes how an argument of a Scratch 2 block is stored in the
+ * Scratch 3 block it is converted to.
+ */
+type argKind int
+
+const (
+	// argInput is an input with a primitive shadow.
+	argInput argKind = iota
+
+	// argColor is an input with a color shadow. Scratch 2 stores colors
+	// as numbers, and Scratch 3 stores them as hex codes.
+	argColor
+
+	// argMenu is an input with a shadow menu block, whose opcode is menu.
+	// The menu's field has the same name as the input.
+	argMenu
+
+	argCondition
+	argSubstack
+	argField
+
+	// argVariable and argList are fields that refer to a variable or list
+	// by name.
+	argVariable
+	argList
+
+	// argBroadcast is an input that holds a broadcast, and
+	// argBroadcastField is a field that refers to one.
+	argBroadcast
+	argBroadcastField
+)
+
+/* argSpec describes an argument of a Scratch 2 block.
+ */
+type argSpec struct {
+	kind      argKind
+	name      string
+	primitive sb3.PrimitiveType
+	menu      string
+
+	// convert changes the value of a field or menu into the form that
+	// Scratch 3 uses, if it is different.
+	convert func (value string) string
+}
+
+/* blockSpec describes how a Scratch 2 block is converted. Args describes the
+ * arguments in the order that Scratch 2 stores them, and fields holds fields
+ * that always have the same value in the Scratch 3 block.
+ */
+type blockSpec struct {
+	opcode string
+	args   []argSpec
+	fields map[string] string
+}
+
+func number   (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypeMathNumber } }
+func positive (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypePositiveNumber } }
+func whole    (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypeWholeNumber } }
+func integer  (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypeInteger } }
+func angle    (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypeAngle } }
+func text     (name string) argSpec { return argSpec { kind: argInput, name: name, primitive: sb3.PrimitiveTypeText } }
+func color    (name string) argSpec { return argSpec { kind: argColor, name: name, primitive: sb3.PrimitiveTypeColor } }
+func condition (name string) argSpec { return argSpec { kind: argCondition, name: name } }
+func substack (name string) argSpec { return argSpec { kind: argSubstack, name: name } }
+func field    (name string) argSpec { return argSpec { kind: argField, name: name } }
+func variable () argSpec { return argSpec { kind: argVariable, name: "VARIABLE" } }
+func list     () argSpec { return argSpec { kind: argList, name: "LIST" } }
+
+func menu (name, opcode string) argSpec {
+	return argSpec { kind: argMenu, name: name, menu: opcode }
+}
+
+func converted (spec argSpec, convert func (string) string) argSpec {
+	spec.convert = convert
+	return spec
+}
+
+/* upper converts menu values that Scratch 3 stores in upper case, such as
+ * graphic effects and dates.
+ */
+func upper (value string) string {
+	return strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+}
+
+// specs maps Scratch 2 opcodes to the Scratch 3 blocks they are converted to.
+// Custom blocks, and blocks that depend on whether they are used by the stage,
+// are converted separately.
+var specs = map[string] blockSpec {
+	// motion
+	"forward:":                      { "motion_movesteps", []argSpec { number("STEPS") }, nil },
+	"turnRight:":                    { "motion_turnright", []argSpec { number("DEGREES") }, nil },
+	"turnLeft:":                     { "motion_turnleft", []argSpec { number("DEGREES") }, nil },
+	"heading:":                      { "motion_pointindirection", []argSpec { angle("DIRECTION") }, nil },
+	"pointTowards:":                 { "motion_pointtowards", []argSpec { menu("TOWARDS", "motion_pointtowards_menu") }, nil },
+	"gotoX:y:":                      { "motion_gotoxy", []argSpec { number("X"), number("Y") }, nil },
+	"gotoSpriteOrMouse:":            { "motion_goto", []argSpec { menu("TO", "motion_goto_menu") }, nil },
+	"glideSecs:toX:y:elapsed:from:": { "motion_glidesecstoxy", []argSpec { number("SECS"), number("X"), number("Y") }, nil },
+	"changeXposBy:":                 { "motion_changexby", []argSpec { number("DX") }, nil },
+	"xpos:":                         { "motion_setx", []argSpec { number("X") }, nil },
+	"changeYposBy:":                 { "motion_changeyby", []argSpec { number("DY") }, nil },
+	"ypos:":                         { "motion_sety", []argSpec { number("Y") }, nil },
+	"bounceOffEdge":                 { "motion_ifonedgebounce", nil, nil },
+	"setRotationStyle":              { "motion_setrotationstyle", []argSpec { field("STYLE") }, nil },
+	"xpos":                          { "motion_xposition", nil, nil },
+	"ypos":                          { "motion_yposition", nil, nil },
+	"heading":                       { "motion_direction", nil, nil },
+
+	// looks
+	"say:duration:elapsed:from:":   { "looks_sayforsecs", []argSpec { text("MESSAGE"), number("SECS") }, nil },
+	"say:":                         { "looks_say", []argSpec { text("MESSAGE") }, nil },
+	"think:duration:elapsed:from:": { "looks_thinkforsecs", []argSpec { text("MESSAGE"), number("SECS") }, nil },
+	"think:":                       { "looks_think", []argSpec { text("MESSAGE") }, nil },
+	"show":                         { "looks_show", nil, nil },
+	"hide":                         { "looks_hide", nil, nil },
+	"lookLike:":                    { "looks_switchcostumeto", []argSpec { menu("COSTUME", "looks_costume") }, nil },
+	"nextCostume":                  { "looks_nextcostume", nil, nil },
+	"startScene":                   { "looks_switchbackdropto", []argSpec { menu("BACKDROP", "looks_backdrops") }, nil },
+	"startSceneAndWait":            { "looks_switchbackdroptoandwait", []argSpec { menu("BACKDROP", "looks_backdrops") }, nil },
+	"nextScene":                    { "looks_nextbackdrop", nil, nil },
+	"changeGraphicEffect:by:":      { "looks_changeeffectby", []argSpec { converted(field("EFFECT"), upper), number("CHANGE") }, nil },
+	"setGraphicEffect:to:":         { "looks_seteffectto", []argSpec { converted(field("EFFECT"), upper), number("VALUE") }, nil },
+	"filterReset":                  { "looks_cleargraphiceffects", nil, nil },
+	"changeSizeBy:":                { "looks_changesizeby", []argSpec { number("CHANGE") }, nil },
+	"setSizeTo:":                   { "looks_setsizeto", []argSpec { number("SIZE") }, nil },
+	"comeToFront":                  { "looks_gotofrontback", nil, map[string] string { "FRONT_BACK": "front" } },
+	"goBackByLayers:":              { "looks_goforwardbackwardlayers", []argSpec { integer("NUM") }, map[string] string { "FORWARD_BACKWARD": "backward" } },
+	"costumeIndex":                 { "looks_costumenumbername", nil, map[string] string { "NUMBER_NAME": "number" } },
+	"sceneName":                    { "looks_backdropnumbername", nil, map[string] string { "NUMBER_NAME": "name" } },
+	"backgroundIndex":              { "looks_backdropnumbername", nil, map[string] string { "NUMBER_NAME": "number" } },
+	"scale":                        { "looks_size", nil, nil },
+
+	// sound
+	"playSound:":           { "sound_play", []argSpec { menu("SOUND_MENU", "sound_sounds_menu") }, nil },
+	"doPlaySoundAndWait":   { "sound_playuntildone", []argSpec { menu("SOUND_MENU", "sound_sounds_menu") }, nil },
+	"stopAllSounds":        { "sound_stopallsounds", nil, nil },
+	"changeVolumeBy:":      { "sound_changevolumeby", []argSpec { number("VOLUME") }, nil },
+	"setVolumeTo:":         { "sound_setvolumeto", []argSpec { number("VOLUME") }, nil },
+	"volume":               { "sound_volume", nil, nil },
+	"rest:elapsed:from:":   { "music_restForBeats", []argSpec { number("BEATS") }, nil },
+	"changeTempoBy:":       { "music_changeTempo", []argSpec { number("TEMPO") }, nil },
+	"setTempoTo:":          { "music_setTempo", []argSpec { number("TEMPO") }, nil },
+	"tempo":                { "music_getTempo", nil, nil },
+	"noteOn:duration:elapsed:from:": { "music_playNoteForBeats", []argSpec { menu("NOTE", "note"), number("BEATS") }, nil },
+
+	// pen
+	"clearPenTrails":   { "pen_clear", nil, nil },
+	"stampCostume":     { "pen_stamp", nil, nil },
+	"putPenDown":       { "pen_penDown", nil, nil },
+	"putPenUp":         { "pen_penUp", nil, nil },
+	"penColor:":        { "pen_setPenColorToColor", []argSpec { color("COLOR") }, nil },
+	"changePenHueBy:":  { "pen_changePenHueBy", []argSpec { number("HUE") }, nil },
+	"setPenHueTo:":     { "pen_setPenHueToNumber", []argSpec { number("HUE") }, nil },
+	"changePenShadeBy:": { "pen_changePenShadeBy", []argSpec { number("SHADE") }, nil },
+	"setPenShadeTo:":   { "pen_setPenShadeToNumber", []argSpec { number("SHADE") }, nil },
+	"changePenSizeBy:": { "pen_changePenSizeBy", []argSpec { number("SIZE") }, nil },
+	"penSize:":         { "pen_setPenSizeTo", []argSpec { number("SIZE") }, nil },
+
+	// events
+	"whenGreenFlag":         { "event_whenflagclicked", nil, nil },
+	"whenKeyPressed":        { "event_whenkeypressed", []argSpec { field("KEY_OPTION") }, nil },
+	"whenSceneStarts":       { "event_whenbackdropswitchesto", []argSpec { field("BACKDROP") }, nil },
+	"whenSensorGreaterThan": { "event_whengreaterthan", []argSpec { converted(field("WHENGREATERTHANMENU"), upper), number("VALUE") }, nil },
+	"whenIReceive":          { "event_whenbroadcastreceived", []argSpec { { kind: argBroadcastField, name: "BROADCAST_OPTION" } }, nil },
+	"broadcast:":            { "event_broadcast", []argSpec { { kind: argBroadcast, name: "BROADCAST_INPUT" } }, nil },
+	"doBroadcastAndWait":    { "event_broadcastandwait", []argSpec { { kind: argBroadcast, name: "BROADCAST_INPUT" } }, nil },
+
+	// control
+	"wait:elapsed:from:": { "control_wait", []argSpec { positive("DURATION") }, nil },
+	"doRepeat":           { "control_repeat", []argSpec { whole("TIMES"), substack("SUBSTACK") }, nil },
+	"doForever":          { "control_forever", []argSpec { substack("SUBSTACK") }, nil },
+	"doIf":               { "control_if", []argSpec { condition("CONDITION"), substack("SUBSTACK") }, nil },
+	"doIfElse":           { "control_if_else", []argSpec { condition("CONDITION"), substack("SUBSTACK"), substack("SUBSTACK2") }, nil },
+	"doWaitUntil":        { "control_wait_until", []argSpec { condition("CONDITION") }, nil },
+	"doUntil":            { "control_repeat_until", []argSpec { condition("CONDITION"), substack("SUBSTACK") }, nil },
+	"doWhile":            { "control_while", []argSpec { condition("CONDITION"), substack("SUBSTACK") }, nil },
+	"whenCloned":         { "control_start_as_clone", nil, nil },
+	"createCloneOf":      { "control_create_clone_of", []argSpec { menu("CLONE_OPTION", "control_create_clone_of_menu") }, nil },
+	"deleteClone":        { "control_delete_this_clone", nil, nil },
+
+	// sensing
+	"touching:":       { "sensing_touchingobject", []argSpec { menu("TOUCHINGOBJECTMENU", "sensing_touchingobjectmenu") }, nil },
+	"touchingColor:":  { "sensing_touchingcolor", []argSpec { color("COLOR") }, nil },
+	"color:sees:":     { "sensing_coloristouchingcolor", []argSpec { color("COLOR"), color("COLOR2") }, nil },
+	"distanceTo:":     { "sensing_distanceto", []argSpec { menu("DISTANCETOMENU", "sensing_distancetomenu") }, nil },
+	"doAsk":           { "sensing_askandwait", []argSpec { text("QUESTION") }, nil },
+	"answer":          { "sensing_answer", nil, nil },
+	"keyPressed:":     { "sensing_keypressed", []argSpec { menu("KEY_OPTION", "sensing_keyoptions") }, nil },
+	"mousePressed":    { "sensing_mousedown", nil, nil },
+	"mouseX":          { "sensing_mousex", nil, nil },
+	"mouseY":          { "sensing_mousey", nil, nil },
+	"soundLevel":      { "sensing_loudness", nil, nil },
+	"timer":           { "sensing_timer", nil, nil },
+	"timerReset":      { "sensing_resettimer", nil, nil },
+	"getAttribute:of:": { "sensing_of", []argSpec { field("PROPERTY"), menu("OBJECT", "sensing_of_object_menu") }, nil },
+	"timeAndDate":     { "sensing_current", []argSpec { converted(field("CURRENTMENU"), upper) }, nil },
+	"timestamp":       { "sensing_dayssince2000", nil, nil },
+	"getUserName":     { "sensing_username", nil, nil },
+
+	// operators
+	"+":                   { "operator_add", []argSpec { number("NUM1"), number("NUM2") }, nil },
+	"-":                   { "operator_subtract", []argSpec { number("NUM1"), number("NUM2") }, nil },
+	"*":                   { "operator_multiply", []argSpec { number("NUM1"), number("NUM2") }, nil },
+	"/":                   { "operator_divide", []argSpec { number("NUM1"), number("NUM2") }, nil },
+	"%":                   { "operator_mod", []argSpec { number("NUM1"), number("NUM2") }, nil },
+	"randomFrom:to:":      { "operator_random", []argSpec { number("FROM"), number("TO") }, nil },
+	"<":                   { "operator_lt", []argSpec { text("OPERAND1"), text("OPERAND2") }, nil },
+	">":                   { "operator_gt", []argSpec { text("OPERAND1"), text("OPERAND2") }, nil },
+	"=":                   { "operator_equals", []argSpec { text("OPERAND1"), text("OPERAND2") }, nil },
+	"&":                   { "operator_and", []argSpec { condition("OPERAND1"), condition("OPERAND2") }, nil },
+	"|":                   { "operator_or", []argSpec { condition("OPERAND1"), condition("OPERAND2") }, nil },
+	"not":                 { "operator_not", []argSpec { condition("OPERAND") }, nil },
+	"concatenate:with:":   { "operator_join", []argSpec { text("STRING1"), text("STRING2") }, nil },
+	"letter:of:":          { "operator_letter_of", []argSpec { whole("LETTER"), text("STRING") }, nil },
+	"stringLength:":       { "operator_length", []argSpec { text("STRING") }, nil },
+	"rounded":             { "operator_round", []argSpec { number("NUM") }, nil },
+	"computeFunction:of:": { "operator_mathop", []argSpec { field("OPERATOR"), number("NUM") }, nil },
+
+	// variables and lists
+	"readVariable":       { "data_variable", []argSpec { variable() }, nil },
+	"setVar:to:":         { "data_setvariableto", []argSpec { variable(), text("VALUE") }, nil },
+	"changeVar:by:":      { "data_changevariableby", []argSpec { variable(), number("VALUE") }, nil },
+	"showVariable:":      { "data_showvariable", []argSpec { variable() }, nil },
+	"hideVariable:":      { "data_hidevariable", []argSpec { variable() }, nil },
+	"contentsOfList:":    { "data_listcontents", []argSpec { list() }, nil },
+	"append:toList:":     { "data_addtolist", []argSpec { text("ITEM"), list() }, nil },
+	"deleteLine:ofList:": { "data_deleteoflist", []argSpec { integer("INDEX"), list() }, nil },
+	"insert:at:ofList:":  { "data_insertatlist", []argSpec { text("ITEM"), integer("INDEX"), list() }, nil },
+	"setLine:ofList:to:": { "data_replaceitemoflist", []argSpec { integer("INDEX"), list(), text("ITEM") }, nil },
+	"getLine:ofList:":    { "data_itemoflist", []argSpec { integer("INDEX"), list() }, nil },
+	"lineCountOfList:":   { "data_lengthoflist", []argSpec { list() }, nil },
+	"list:contains:":     { "data_listcontainsitem", []argSpec { list(), text("ITEM") }, nil },
+	"showList:":          { "data_showlist", []argSpec { list() }, nil },
+	"hideList:":          { "data_hidelist", []argSpec { list() }, nil },
+}
@@ -0,0 +1,234 @@
+package sb3
+
+import "io"
+import "fmt"
+import "path"
+import "strings"
+import "crypto/md5"
+import "archive/zip"
+import "encoding/hex"
+
+/* Archive represents an sb3 file, which is a zip archive containing a
+ * project.json file and the costumes and sounds of the project. Each asset is
+ * named after the MD5 hash of its contents, followed by its file extension.
+ */
+type Archive struct {
+	Project *Project
+	assets  map[string] *zip.File
+}
+
+/* Open reads an sb3 file and parses its project.json file. Assets are not read
+ * until they are requested.
+ */
+func Open (reader io.ReaderAt, size int64) (archive *Archive, err error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sb3 file: %v", err)
+	}
+
+	archive = &Archive { assets: make(map[string] *zip.File) }
+	var projectFile *zip.File
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() { continue }
+		name := path.Base(file.Name)
+		if name == "project.json" {
+			projectFile = file
+		} else {
+			archive.assets[name] = file
+		}
+	}
+
+	if projectFile == nil {
+		return nil, fmt.Errorf("cannot open sb3 file: no project.json")
+	}
+	data, err := readFile(projectFile)
+	if err != nil { return nil, err }
+	archive.Project, err = Parse(data)
+	if err != nil { return nil, err }
+	return
+}
+
+/* Assets returns the names of all assets stored in the archive, in sorted
+ * order.
+ */
+func (archive *Archive) Assets () (names []string) {
+	return SortedKeys(archive.assets)
+}
+
+/* HasAsset returns whether the archive contains an asset with the specified
+ * name.
+ */
+func (archive *Archive) HasAsset (name string) (has bool) {
+	_, has = archive.assets[name]
+	return
+}
+
+/* Asset reads the asset with the specified name from the archive.
+ */
+func (archive *Archive) Asset (name string) (data []byte, err error) {
+	file, ok := archive.assets[name]
+	if !ok {
+		return nil, fmt.Errorf("asset %s is not in sb3 file", name)
+	}
+	return readFile(file)
+}
+
+/* AssetMap reads every asset in the archive, and returns them in a map keyed by
+ * their names. The result can be passed to Write.
+ */
+func (archive *Archive) AssetMap () (assets map[string] []byte, err error) {
+	assets = make(map[string] []byte)
+	for name := range archive.assets {
+		assets[name], err = archive.Asset(name)
+		if err != nil { return nil, err }
+	}
+	return
+}
+
+/* Verify checks that every costume and sound in the project is present in the
+ * archive, and that the contents of each one match its MD5 hash. All problems
+ * that are found are described in the returned error.
+ */
+func (archive *Archive) Verify () (err error) {
+	problems := []string { }
+	for _, name := range ReferencedAssets(archive.Project) {
+		if !archive.HasAsset(name) {
+			problems = append(problems, name + " is missing")
+			continue
+		}
+
+		data, err := archive.Asset(name)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		err = VerifyAsset(name, data)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	// the asset ID is stored separately from the file name, so make sure
+	// they agree
+	for _, target := range archive.Project.Targets {
+		for _, costume := range target.Costumes {
+			if !strings.HasPrefix(costume.FileName(), costume.AssetID) {
+				problems = append(problems, fmt.Sprintf (
+					"costume %s has asset ID %s but file %s",
+					costume.Name, costume.AssetID,
+					costume.FileName()))
+			}
+		}
+		for _, sound := range target.Sounds {
+			if !strings.HasPrefix(sound.FileName(), sound.AssetID) {
+				problems = append(problems, fmt.Sprintf (
+					"sound %s has asset ID %s but file %s",
+					sound.Name, sound.AssetID,
+					sound.FileName()))
+			}
+		}
+	}
+	
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid sb3 file: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+/* Write writes an sb3 file containing a project and its assets. Only assets
+ * that are used by the project are written, and an error is returned if any of
+ * them are missing or do not match their MD5 hash.
+ */
+func Write (
+	writer  io.Writer,
+	project *Project,
+	assets  map[string] []byte,
+) (
+	err error,
+) {
+	projectJSON, err := project.Marshal()
+	if err != nil { return }
+
+	names := ReferencedAssets(project)
+	for _, name := range names {
+		data, ok := assets[name]
+		if !ok {
+			return fmt.Errorf("cannot write sb3 file: %s is missing", name)
+		}
+		err = VerifyAsset(name, data)
+		if err != nil {
+			return fmt.Errorf("cannot write sb3 file: %v", err)
+		}
+	}
+
+	zipWriter := zip.NewWriter(writer)
+	err = writeFile(zipWriter, "project.json", projectJSON)
+	if err != nil { return }
+	for _, name := range names {
+		err = writeFile(zipWriter, name, assets[name])
+		if err != nil { return }
+	}
+	return zipWriter.Close()
+}
+
+/* ReferencedAssets returns the file names of all costumes and sounds used by a
+ * project, in sorted order and without duplicates.
+ */
+func ReferencedAssets (project *Project) (names []string) {
+	found := map[string] bool { }
+	for _, target := range project.Targets {
+		for _, costume := range target.Costumes {
+			found[costume.FileName()] = true
+		}
+		for _, sound := range target.Sounds {
+			found[sound.FileName()] = true
+		}
+	}
+
+	return SortedKeys(found)
+}
+
+/* AssetName returns the name that an asset with the specified contents and
+ * file extension should have.
+ */
+func AssetName (data []byte, extension string) (name string) {
+	return AssetID(data) + "." + extension
+}
+
+/* AssetID returns the MD5 hash of an asset's contents, which Scratch uses to
+ * identify it.
+ */
+func AssetID (data []byte) (id string) {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+/* VerifyAsset checks that the contents of an asset match the MD5 hash in its
+ * name.
+ */
+func VerifyAsset (name string, data []byte) (err error) {
+	expected := strings.TrimSuffix(name, path.Ext(name))
+	actual   := AssetID(data)
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("%s has MD5 hash %s", name, actual)
+	}
+	return nil
+}
+
+/* readFile reads the entire contents of a file inside of a zip archive.
+ */
+func readFile (file *zip.File) (data []byte, err error) {
+	reader, err := file.Open()
+	if err != nil { return }
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+/* writeFile writes a file to a zip archive.
+ */
+func writeFile (writer *zip.Writer, name string, data []byte) (err error) {
+	fileWriter, err := writer.Create(name)
+	if err != nil { return }
+	_, err = fileWriter.Write(data)
+	return
+}
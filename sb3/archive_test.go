@@ -0,0 +1,177 @@
+package sb3
+
+import "bytes"
+import "strings"
+import "testing"
+import "archive/zip"
+
+var (
+	costumeData = []byte(`<svg width="10" height="10"/>`)
+	soundData   = []byte("RIFF meow")
+	costumeName = AssetName(costumeData, "svg")
+	soundName   = AssetName(soundData, "wav")
+)
+
+/* archiveProject returns the JSON of a project whose stage and sprite share a
+ * costume, and whose sprite has a sound.
+ */
+func archiveProject () (data string) {
+	costume := `{"assetId":"` + AssetID(costumeData) + `","name":"costume1",` +
+		`"md5ext":"` + costumeName + `","dataFormat":"svg",` +
+		`"rotationCenterX":5,"rotationCenterY":5}`
+	sound := `{"assetId":"` + AssetID(soundData) + `","name":"meow",` +
+		`"md5ext":"` + soundName + `","dataFormat":"wav",` +
+		`"format":"","rate":22050,"sampleCount":4}`
+	return `{"targets":[` +
+		`{"isStage":true,"name":"Stage","costumes":[` + costume + `],"sounds":[]},` +
+		`{"isStage":false,"name":"Cat","costumes":[` + costume + `],"sounds":[` + sound + `]}],` +
+		`"monitors":[],"extensions":[],"meta":{"semver":"3.0.0"}}`
+}
+
+/* zipFiles creates a zip archive containing the specified files.
+ */
+func zipFiles (test *testing.T, files map[string] string) (data []byte) {
+	test.Helper()
+	buffer := bytes.Buffer { }
+	writer := zip.NewWriter(&buffer)
+	for _, name := range SortedKeys(files) {
+		err := writeFile(writer, name, []byte(files[name]))
+		if err != nil { test.Fatal(err) }
+	}
+	err := writer.Close()
+	if err != nil { test.Fatal(err) }
+	return buffer.Bytes()
+}
+
+/* openBytes opens an sb3 file that is held in memory.
+ */
+func openBytes (data []byte) (archive *Archive, err error) {
+	return Open(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestArchiveRoundTrip (test *testing.T) {
+	project, err := Parse([]byte(archiveProject()))
+	if err != nil { test.Fatal(err) }
+	names := ReferencedAssets(project)
+	if strings.Join(names, " ") != strings.Join(SortedKeys(map[string] bool {
+		costumeName: true, soundName: true,
+	}), " ") {
+		test.Fatalf("referenced assets are %v", names)
+	}
+
+	// assets that the project does not use are left out
+	buffer := bytes.Buffer { }
+	err = Write(&buffer, project, map[string] []byte {
+		costumeName:           costumeData,
+		soundName:             soundData,
+		AssetName(nil, "png"): nil,
+	})
+	if err != nil { test.Fatal(err) }
+
+	archive, err := openBytes(buffer.Bytes())
+	if err != nil { test.Fatal(err) }
+	if strings.Join(archive.Assets(), " ") != strings.Join(names, " ") {
+		test.Fatalf("archive holds %v, expected %v", archive.Assets(), names)
+	}
+	if archive.Project.Target("Cat").Sound("meow") == nil {
+		test.Fatal("sound was not kept")
+	}
+	assets, err := archive.AssetMap()
+	if err != nil { test.Fatal(err) }
+	if !bytes.Equal(assets[soundName], soundData) {
+		test.Fatalf("sound holds %q", assets[soundName])
+	}
+	err = archive.Verify()
+	if err != nil { test.Fatal(err) }
+
+	_, err = archive.Asset("missing.svg")
+	if err == nil { test.Fatal("missing asset was read") }
+}
+
+func TestWriteChecksAssets (test *testing.T) {
+	project, err := Parse([]byte(archiveProject()))
+	if err != nil { test.Fatal(err) }
+
+	for _, testCase := range []struct {
+		assets   map[string] []byte
+		expected string
+	} {
+		{
+			map[string] []byte { costumeName: costumeData },
+			soundName + " is missing",
+		}, {
+			map[string] []byte { costumeName: costumeData, soundName: []byte("bark") },
+			soundName + " has MD5 hash " + AssetID([]byte("bark")),
+		},
+	} {
+		err := Write(&bytes.Buffer { }, project, testCase.assets)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Errorf("error is %v, expected %q", err, testCase.expected)
+		}
+	}
+}
+
+func TestVerifyArchive (test *testing.T) {
+	// the costume is missing, the sound does not match its hash, and the
+	// asset ID of the sound does not match its file name
+	project := strings.Replace (
+		archiveProject(),
+		`"assetId":"` + AssetID(soundData) + `"`,
+		`"assetId":"0123"`, 1)
+	archive, err := openBytes(zipFiles(test, map[string] string {
+		"project.json": project,
+		soundName:      "bark",
+	}))
+	if err != nil { test.Fatal(err) }
+
+	err = archive.Verify()
+	if err == nil { test.Fatal("broken archive was verified") }
+	for _, problem := range []string {
+		costumeName + " is missing",
+		soundName + " has MD5 hash",
+		"sound meow has asset ID 0123 but file " + soundName,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			test.Errorf("%q is not reported in: %v", problem, err)
+		}
+	}
+}
+
+func TestOpenInvalidArchive (test *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		data     []byte
+		expected string
+	} {
+		{ "not a zip file", []byte("project"), "cannot open sb3 file" },
+		{
+			"no project.json",
+			zipFiles(test, map[string] string { costumeName: string(costumeData) }),
+			"no project.json",
+		}, {
+			"null target",
+			zipFiles(test, map[string] string { "project.json": `{"targets":[null]}` }),
+			"target 0 is null",
+		}, {
+			"null costume",
+			zipFiles(test, map[string] string {
+				"project.json": `{"targets":[{"isStage":true,"name":"Stage","costumes":[null]}]}`,
+			}),
+			"costume 0 in Stage is null",
+		},
+	} {
+		_, err := openBytes(testCase.data)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Errorf("%s: error is %v, expected %q", testCase.name, err, testCase.expected)
+		}
+	}
+}
+
+func TestVerifyAsset (test *testing.T) {
+	err := VerifyAsset(costumeName, costumeData)
+	if err != nil { test.Fatal(err) }
+	err = VerifyAsset(strings.ToUpper(AssetID(costumeData)) + ".svg", costumeData)
+	if err != nil { test.Fatal("hash in upper case was not accepted") }
+	err = VerifyAsset(costumeName, soundData)
+	if err == nil { test.Fatal("asset with the wrong contents was accepted") }
+}
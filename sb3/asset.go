@@ -0,0 +1,86 @@
+package sb3
+
+import "encoding/json"
+
+/* Costume represents a costume of a sprite or backdrop of the stage. MD5Ext is
+ * the name of the costume's file, which is its MD5 hash followed by its file
+ * extension.
+ */
+type Costume struct {
+	AssetID          string  `json:"assetId"`
+	Name             string  `json:"name"`
+	BitmapResolution int     `json:"bitmapResolution,omitempty"`
+	MD5Ext           string  `json:"md5ext,omitempty"`
+	DataFormat       string  `json:"dataFormat"`
+	RotationCenterX  float64 `json:"rotationCenterX"`
+	RotationCenterY  float64 `json:"rotationCenterY"`
+
+	Extra map[string] json.RawMessage `json:"-"`
+}
+
+/* UnmarshalJSON decodes a costume.
+ */
+func (costume *Costume) UnmarshalJSON (data []byte) (err error) {
+	type costumeJSON Costume
+	decoded := costumeJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+	*costume = Costume(decoded)
+	costume.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes a costume.
+ */
+func (costume *Costume) MarshalJSON () (data []byte, err error) {
+	type costumeJSON Costume
+	return marshalExtra(costumeJSON(*costume), costume.Extra)
+}
+
+/* FileName returns the name of the costume's file.
+ */
+func (costume *Costume) FileName () (name string) {
+	if costume.MD5Ext != "" { return costume.MD5Ext }
+	return costume.AssetID + "." + costume.DataFormat
+}
+
+/* Sound represents a sound of a sprite or the stage. MD5Ext is the name of the
+ * sound's file, which is its MD5 hash followed by its file extension.
+ */
+type Sound struct {
+	AssetID     string `json:"assetId"`
+	Name        string `json:"name"`
+	DataFormat  string `json:"dataFormat"`
+	Format      string `json:"format"`
+	Rate        int    `json:"rate"`
+	SampleCount int    `json:"sampleCount"`
+	MD5Ext      string `json:"md5ext,omitempty"`
+
+	Extra map[string] json.RawMessage `json:"-"`
+}
+
+/* UnmarshalJSON decodes a sound.
+ */
+func (sound *Sound) UnmarshalJSON (data []byte) (err error) {
+	type soundJSON Sound
+	decoded := soundJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+	*sound = Sound(decoded)
+	sound.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes a sound.
+ */
+func (sound *Sound) MarshalJSON () (data []byte, err error) {
+	type soundJSON Sound
+	return marshalExtra(soundJSON(*sound), sound.Extra)
+}
+
+/* FileName returns the name of the sound's file.
+ */
+func (sound *Sound) FileName () (name string) {
+	if sound.MD5Ext != "" { return sound.MD5Ext }
+	return sound.AssetID + "." + sound.DataFormat
+}
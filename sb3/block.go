@@ -0,0 +1,418 @@
+package sb3
+
+import "fmt"
+import "encoding/json"
+
+/* Block represents a single block in a target's code area. Next and Parent are
+ * blank if the block has no next or parent block. X and Y are only used for top
+ * level blocks.
+ *
+ * Variable and list reporters that sit alone in the code area are stored by
+ * Scratch in a compact array form. For these blocks, Primitive is set, and only
+ * TopLevel, X, and Y are used along with it.
+ */
+type Block struct {
+	ID        string
+	Opcode    string
+	Next      string
+	Parent    string
+	Inputs    map[string] Input
+	Fields    map[string] Field
+	Shadow    bool
+	TopLevel  bool
+	X         float64
+	Y         float64
+	Mutation  *Mutation
+	Comment   string
+	Primitive *Primitive
+
+	// Extra holds keys of the object form that are not listed above.
+	Extra map[string] json.RawMessage
+}
+
+/* blockJSON is the JSON encoding of a block.
+ */
+type blockJSON struct {
+	Opcode   string            `json:"opcode"`
+	Next     *string           `json:"next"`
+	Parent   *string           `json:"parent"`
+	Inputs   map[string] Input `json:"inputs"`
+	Fields   map[string] Field `json:"fields"`
+	Shadow   bool              `json:"shadow"`
+	TopLevel bool              `json:"topLevel"`
+	X        *float64          `json:"x,omitempty"`
+	Y        *float64          `json:"y,omitempty"`
+	Mutation *Mutation         `json:"mutation,omitempty"`
+	Comment  string            `json:"comment,omitempty"`
+}
+
+/* UnmarshalJSON decodes a block in either its object or array form.
+ */
+func (block *Block) UnmarshalJSON (data []byte) (err error) {
+	*block = Block { }
+	
+	if len(data) > 0 && data[0] == '[' {
+		array, err := decodeArray(data)
+		if err != nil { return err }
+		
+		block.Primitive = &Primitive { }
+		err = block.Primitive.unmarshalArray(array)
+		if err != nil { return err }
+		
+		if len(array) >= 5 {
+			block.TopLevel = true
+			err = json.Unmarshal(array[3], &block.X)
+			if err != nil { return err }
+			err = json.Unmarshal(array[4], &block.Y)
+			if err != nil { return err }
+		}
+		return nil
+	}
+
+	decoded := blockJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+
+	*block = Block {
+		Opcode:   decoded.Opcode,
+		Inputs:   decoded.Inputs,
+		Fields:   decoded.Fields,
+		Shadow:   decoded.Shadow,
+		TopLevel: decoded.TopLevel,
+		Mutation: decoded.Mutation,
+		Comment:  decoded.Comment,
+	}
+	block.Extra, err = decodeExtra(data, decoded)
+	if err != nil { return }
+	if decoded.Next   != nil { block.Next   = *decoded.Next   }
+	if decoded.Parent != nil { block.Parent = *decoded.Parent }
+	if decoded.X      != nil { block.X      = *decoded.X      }
+	if decoded.Y      != nil { block.Y      = *decoded.Y      }
+	if block.Inputs == nil { block.Inputs = map[string] Input { } }
+	if block.Fields == nil { block.Fields = map[string] Field { } }
+	return
+}
+
+/* MarshalJSON encodes a block.
+ */
+func (block *Block) MarshalJSON () (data []byte, err error) {
+	if block.Primitive != nil {
+		array := block.Primitive.array()
+		if block.TopLevel {
+			array = append(array, block.X, block.Y)
+		}
+		return marshal(array)
+	}
+
+	encoded := blockJSON {
+		Opcode:   block.Opcode,
+		Inputs:   block.Inputs,
+		Fields:   block.Fields,
+		Shadow:   block.Shadow,
+		TopLevel: block.TopLevel,
+		Mutation: block.Mutation,
+		Comment:  block.Comment,
+	}
+	if block.Next   != "" { encoded.Next   = &block.Next   }
+	if block.Parent != "" { encoded.Parent = &block.Parent }
+	if block.TopLevel {
+		encoded.X = &block.X
+		encoded.Y = &block.Y
+	}
+	if encoded.Inputs == nil { encoded.Inputs = map[string] Input { } }
+	if encoded.Fields == nil { encoded.Fields = map[string] Field { } }
+	return marshalExtra(encoded, block.Extra)
+}
+
+/* ShadowType describes how an input relates to its shadow block, which is the
+ * block that shows the input's default value.
+ */
+type ShadowType int
+
+const (
+	// ShadowTypeSame is used when the input only contains its shadow.
+	ShadowTypeSame ShadowType = 1
+
+	// ShadowTypeNone is used when the input contains a block, and has no
+	// shadow. This is the case for boolean and substack inputs.
+	ShadowTypeNone ShadowType = 2
+
+	// ShadowTypeObscured is used when the input contains a block that is
+	// covering up its shadow.
+	ShadowTypeObscured ShadowType = 3
+)
+
+/* Input represents an input of a block. Block is the value of the input, and
+ * Shadow is the shadow that the block is covering if the shadow type is
+ * ShadowTypeObscured.
+ */
+type Input struct {
+	ShadowType ShadowType
+	Block      InputValue
+	Shadow     InputValue
+}
+
+/* UnmarshalJSON decodes an input.
+ */
+func (input *Input) UnmarshalJSON (data []byte) (err error) {
+	array, err := decodeArray(data)
+	if err != nil { return }
+	if len(array) < 2 {
+		return fmt.Errorf("input has %d elements, expected 2", len(array))
+	}
+	
+	*input = Input { }
+	err = json.Unmarshal(array[0], &input.ShadowType)
+	if err != nil { return }
+	err = input.Block.UnmarshalJSON(array[1])
+	if err != nil { return }
+	if len(array) > 2 {
+		err = input.Shadow.UnmarshalJSON(array[2])
+	}
+	return
+}
+
+/* MarshalJSON encodes an input.
+ */
+func (input Input) MarshalJSON () (data []byte, err error) {
+	array := []any { input.ShadowType, input.Block }
+	if input.ShadowType == ShadowTypeObscured {
+		array = append(array, input.Shadow)
+	}
+	return marshal(array)
+}
+
+/* InputValue is the value of an input, which is either the ID of a block, a
+ * primitive, or nothing.
+ */
+type InputValue struct {
+	BlockID   string
+	Primitive *Primitive
+}
+
+/* IsEmpty returns whether the input value contains nothing.
+ */
+func (value InputValue) IsEmpty () (empty bool) {
+	return value.BlockID == "" && value.Primitive == nil
+}
+
+/* UnmarshalJSON decodes an input value.
+ */
+func (value *InputValue) UnmarshalJSON (data []byte) (err error) {
+	*value = InputValue { }
+	
+	decoded, err := decodeValue(data)
+	if err != nil { return }
+	
+	switch decoded := decoded.(type) {
+	case nil:
+	case string:
+		value.BlockID = decoded
+	case []any:
+		array, err := decodeArray(data)
+		if err != nil { return err }
+		value.Primitive = &Primitive { }
+		return value.Primitive.unmarshalArray(array)
+	default:
+		return fmt.Errorf("invalid input value %s", string(data))
+	}
+	return
+}
+
+/* MarshalJSON encodes an input value.
+ */
+func (value InputValue) MarshalJSON () (data []byte, err error) {
+	if value.Primitive != nil { return marshal(value.Primitive.array()) }
+	return marshal(nullableString(value.BlockID))
+}
+
+/* PrimitiveType represents the type of a primitive.
+ */
+type PrimitiveType int
+
+const (
+	PrimitiveTypeMathNumber     PrimitiveType = 4
+	PrimitiveTypePositiveNumber PrimitiveType = 5
+	PrimitiveTypeWholeNumber    PrimitiveType = 6
+	PrimitiveTypeInteger        PrimitiveType = 7
+	PrimitiveTypeAngle          PrimitiveType = 8
+	PrimitiveTypeColor          PrimitiveType = 9
+	PrimitiveTypeText           PrimitiveType = 10
+	PrimitiveTypeBroadcast      PrimitiveType = 11
+	PrimitiveTypeVariable       PrimitiveType = 12
+	PrimitiveTypeList           PrimitiveType = 13
+)
+
+/* Primitive is a compact form that Scratch uses for simple shadow blocks, such
+ * as number and text inputs, and for variable, list, and broadcast references.
+ * For the first kind, Value holds the value of the input. For references, Name
+ * and ID identify what is being referred to.
+ */
+type Primitive struct {
+	Type  PrimitiveType
+	Value any
+	Name  string
+	ID    string
+}
+
+/* IsReference returns whether the primitive refers to a broadcast, variable,
+ * or list.
+ */
+func (primitive *Primitive) IsReference () (reference bool) {
+	return primitive.Type >= PrimitiveTypeBroadcast &&
+		primitive.Type <= PrimitiveTypeList
+}
+
+/* unmarshalArray decodes a primitive from its array form.
+ */
+func (primitive *Primitive) unmarshalArray (array []json.RawMessage) (err error) {
+	*primitive = Primitive { }
+	if len(array) < 2 {
+		return fmt.Errorf("primitive has %d elements, expected 2", len(array))
+	}
+	
+	err = json.Unmarshal(array[0], &primitive.Type)
+	if err != nil { return }
+
+	if !primitive.IsReference() {
+		primitive.Value, err = decodeValue(array[1])
+		return
+	}
+	
+	if len(array) < 3 {
+		return fmt.Errorf (
+			"reference primitive has %d elements, expected 3",
+			len(array))
+	}
+	err = json.Unmarshal(array[1], &primitive.Name)
+	if err != nil { return }
+	return json.Unmarshal(array[2], &primitive.ID)
+}
+
+/* array returns the array form of a primitive.
+ */
+func (primitive *Primitive) array () (array []any) {
+	if primitive.IsReference() {
+		return []any { primitive.Type, primitive.Name, primitive.ID }
+	}
+	return []any { primitive.Type, primitive.Value }
+}
+
+/* Field represents a field of a block, which is a value that is edited
+ * directly on the block instead of through an input, such as a dropdown menu.
+ * Fields that refer to variables, lists, and broadcasts have an ID.
+ */
+type Field struct {
+	Value any
+	ID    string
+
+	// short is set if the field was stored without an ID element, so that
+	// it can be written back the same way.
+	short bool
+}
+
+/* UnmarshalJSON decodes a field.
+ */
+func (field *Field) UnmarshalJSON (data []byte) (err error) {
+	array, err := decodeArray(data)
+	if err != nil { return }
+	if len(array) < 1 {
+		return fmt.Errorf("field is empty")
+	}
+
+	*field = Field { }
+	field.Value, err = decodeValue(array[0])
+	if err != nil { return }
+	if len(array) < 2 {
+		field.short = true
+		return
+	}
+	field.ID, err = decodeNullableString(array[1])
+	return
+}
+
+/* MarshalJSON encodes a field.
+ */
+func (field Field) MarshalJSON () (data []byte, err error) {
+	if field.short && field.ID == "" {
+		return marshal([]any { field.Value })
+	}
+	return marshal([]any { field.Value, nullableString(field.ID) })
+}
+
+/* String returns the value of the field as a string.
+ */
+func (field Field) String () (value string) {
+	return fmt.Sprint(field.Value)
+}
+
+/* Mutation holds extra information about a block whose shape can change. It is
+ * used by custom blocks, and by control_stop to record whether it is a cap
+ * block. Other attributes, such as the blockInfo of extension blocks, are kept
+ * in Extra.
+ */
+type Mutation struct {
+	TagName          string `json:"tagName"`
+	Children         []any  `json:"children"`
+	ProcCode         string `json:"proccode,omitempty"`
+	ArgumentIDs      string `json:"argumentids,omitempty"`
+	ArgumentNames    string `json:"argumentnames,omitempty"`
+	ArgumentDefaults string `json:"argumentdefaults,omitempty"`
+	Warp             any    `json:"warp,omitempty"`
+	HasNext          any    `json:"hasnext,omitempty"`
+
+	Extra map[string] json.RawMessage `json:"-"`
+}
+
+/* UnmarshalJSON decodes a mutation.
+ */
+func (mutation *Mutation) UnmarshalJSON (data []byte) (err error) {
+	type mutationJSON Mutation
+	decoded := mutationJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+	*mutation = Mutation(decoded)
+	mutation.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes a mutation.
+ */
+func (mutation *Mutation) MarshalJSON () (data []byte, err error) {
+	type mutationJSON Mutation
+	encoded := mutationJSON(*mutation)
+	if encoded.Children == nil { encoded.Children = []any { } }
+	return marshalExtra(encoded, mutation.Extra)
+}
+
+/* ArgumentIDList decodes the list of argument IDs of a custom block.
+ */
+func (mutation *Mutation) ArgumentIDList () (ids []string, err error) {
+	return decodeStringList(mutation.ArgumentIDs)
+}
+
+/* ArgumentNameList decodes the list of argument names of a custom block.
+ */
+func (mutation *Mutation) ArgumentNameList () (names []string, err error) {
+	return decodeStringList(mutation.ArgumentNames)
+}
+
+/* IsWarp returns whether a custom block runs without screen refresh. Scratch
+ * stores this as either a boolean or a string.
+ */
+func (mutation *Mutation) IsWarp () (warp bool) {
+	switch value := mutation.Warp.(type) {
+	case bool:   return value
+	case string: return value == "true"
+	}
+	return
+}
+
+/* decodeStringList decodes a JSON encoded list of strings that is stored inside
+ * of a string. A blank string is treated as an empty list.
+ */
+func decodeStringList (data string) (list []string, err error) {
+	if data == "" { return }
+	err = json.Unmarshal([]byte(data), &list)
+	return
+}
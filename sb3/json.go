@@ -0,0 +1,132 @@
+package sb3
+
+import "sync"
+import "bytes"
+import "reflect"
+import "strings"
+import "encoding/json"
+
+/* decodeValue decodes a JSON value into a Go value. Numbers are decoded as
+ * json.Number so that they are written back exactly as they were read.
+ */
+func decodeValue (data []byte) (value any, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	return
+}
+
+/* decodeArray decodes a JSON array into a slice of raw values.
+ */
+func decodeArray (data []byte) (array []json.RawMessage, err error) {
+	err = json.Unmarshal(data, &array)
+	return
+}
+
+/* marshal encodes a value as JSON without escaping HTML characters, because
+ * the Scratch editor does not do so either.
+ */
+func marshal (value any) (data []byte, err error) {
+	buffer := &bytes.Buffer { }
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(value)
+	if err != nil { return }
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+/* nullableString encodes an empty string as null.
+ */
+func nullableString (value string) (data any) {
+	if value == "" { return nil }
+	return value
+}
+
+/* decodeNullableString decodes a JSON string that may be null. Null is decoded
+ * as an empty string.
+ */
+func decodeNullableString (data []byte) (value string, err error) {
+	var pointer *string
+	err = json.Unmarshal(data, &pointer)
+	if pointer != nil { value = *pointer }
+	return
+}
+
+// knownKeys caches the keys of each type that has been passed to decodeExtra.
+var knownKeys sync.Map
+
+/* decodeExtra decodes the keys of a JSON object that do not belong to any field
+ * of structure, which is a value of the type that the object was decoded into.
+ * Keys are compared without regard to case, like encoding/json does.
+ */
+func decodeExtra (
+	data      []byte,
+	structure any,
+) (
+	extra map[string] json.RawMessage,
+	err   error,
+) {
+	object := map[string] json.RawMessage { }
+	err = json.Unmarshal(data, &object)
+	if err != nil { return }
+
+	known := keysOf(reflect.TypeOf(structure))
+	for key, value := range object {
+		if known[strings.ToLower(key)] { continue }
+		if extra == nil { extra = map[string] json.RawMessage { } }
+		extra[key] = value
+	}
+	return
+}
+
+/* marshalExtra encodes a value as a JSON object, and adds the extra keys to the
+ * end of it in sorted order. Keys that the object already has are not added
+ * again.
+ */
+func marshalExtra (
+	value any,
+	extra map[string] json.RawMessage,
+) (
+	data []byte,
+	err  error,
+) {
+	data, err = marshal(value)
+	if err != nil || len(extra) == 0 { return }
+
+	known  := keysOf(reflect.TypeOf(value))
+	buffer := bytes.NewBuffer(bytes.TrimSuffix(data, []byte("}")))
+	empty  := buffer.Len() == 1
+	for _, key := range SortedKeys(extra) {
+		if known[strings.ToLower(key)] { continue }
+		if !empty { buffer.WriteByte(',') }
+		empty = false
+
+		encodedKey, err := marshal(key)
+		if err != nil { return nil, err }
+		buffer.Write(encodedKey)
+		buffer.WriteByte(':')
+		err = json.Compact(buffer, extra[key])
+		if err != nil { return nil, err }
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+/* keysOf returns the lower case JSON keys of the fields of a structure type.
+ */
+func keysOf (structure reflect.Type) (keys map[string] bool) {
+	if cached, ok := knownKeys.Load(structure); ok {
+		return cached.(map[string] bool)
+	}
+
+	keys = map[string] bool { }
+	for index := 0; index < structure.NumField(); index ++ {
+		field := structure.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() { continue }
+		if name == "" { name = field.Name }
+		keys[strings.ToLower(name)] = true
+	}
+	knownKeys.Store(structure, keys)
+	return
+}
@@ -0,0 +1,106 @@
+package sb3
+
+import "encoding/json"
+
+/* Monitor represents a variable or list monitor, or a reporter that is being
+ * shown on the stage. SpriteName is blank if the monitor belongs to the stage.
+ * For list monitors, Value is a list of items, and the slider fields are not
+ * used.
+ */
+type Monitor struct {
+	ID         string
+	Mode       string
+	Opcode     string
+	Params     map[string] any
+	SpriteName string
+	Value      any
+	Width      float64
+	Height     float64
+	X          float64
+	Y          float64
+	Visible    bool
+	SliderMin  float64
+	SliderMax  float64
+	IsDiscrete bool
+
+	// Extra holds keys that are not listed above.
+	Extra map[string] json.RawMessage
+}
+
+/* monitorJSON is the JSON encoding of a monitor.
+ */
+type monitorJSON struct {
+	ID         string          `json:"id"`
+	Mode       string          `json:"mode"`
+	Opcode     string          `json:"opcode"`
+	Params     map[string] any `json:"params"`
+	SpriteName *string         `json:"spriteName"`
+	Value      json.RawMessage `json:"value"`
+	Width      float64         `json:"width"`
+	Height     float64         `json:"height"`
+	X          float64         `json:"x"`
+	Y          float64         `json:"y"`
+	Visible    bool            `json:"visible"`
+	SliderMin  *float64        `json:"sliderMin,omitempty"`
+	SliderMax  *float64        `json:"sliderMax,omitempty"`
+	IsDiscrete *bool           `json:"isDiscrete,omitempty"`
+}
+
+/* UnmarshalJSON decodes a monitor.
+ */
+func (monitor *Monitor) UnmarshalJSON (data []byte) (err error) {
+	decoded := monitorJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+
+	*monitor = Monitor {
+		ID:      decoded.ID,
+		Mode:    decoded.Mode,
+		Opcode:  decoded.Opcode,
+		Params:  decoded.Params,
+		Width:   decoded.Width,
+		Height:  decoded.Height,
+		X:       decoded.X,
+		Y:       decoded.Y,
+		Visible: decoded.Visible,
+	}
+	if decoded.SpriteName != nil { monitor.SpriteName = *decoded.SpriteName }
+	if decoded.SliderMin  != nil { monitor.SliderMin  = *decoded.SliderMin  }
+	if decoded.SliderMax  != nil { monitor.SliderMax  = *decoded.SliderMax  }
+	if decoded.IsDiscrete != nil { monitor.IsDiscrete = *decoded.IsDiscrete }
+	if len(decoded.Value) > 0 {
+		monitor.Value, err = decodeValue(decoded.Value)
+		if err != nil { return }
+	}
+	monitor.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes a monitor.
+ */
+func (monitor *Monitor) MarshalJSON () (data []byte, err error) {
+	encoded := monitorJSON {
+		ID:      monitor.ID,
+		Mode:    monitor.Mode,
+		Opcode:  monitor.Opcode,
+		Params:  monitor.Params,
+		Width:   monitor.Width,
+		Height:  monitor.Height,
+		X:       monitor.X,
+		Y:       monitor.Y,
+		Visible: monitor.Visible,
+	}
+	if encoded.Params == nil { encoded.Params = map[string] any { } }
+	if monitor.SpriteName != "" {
+		encoded.SpriteName = &monitor.SpriteName
+	}
+	if monitor.Mode != "list" {
+		encoded.SliderMin  = &monitor.SliderMin
+		encoded.SliderMax  = &monitor.SliderMax
+		encoded.IsDiscrete = &monitor.IsDiscrete
+	}
+	
+	encoded.Value, err = marshal(monitor.Value)
+	if err != nil { return }
+	return marshalExtra(encoded, monitor.Extra)
+}
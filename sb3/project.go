@@ -0,0 +1,169 @@
+/* Package sb3 parses and writes Scratch 3 projects.
+ */
+package sb3
+
+import "fmt"
+import "sort"
+import "strings"
+import "encoding/json"
+
+// CloudSymbol is the prefix of the names of cloud variables.
+const CloudSymbol = "☁ "
+
+/* Project represents the contents of a project.json file inside of an sb3
+ * archive. It can be parsed from JSON using Parse, and converted back using
+ * Marshal. Keys that Scratch 3 does not write, such as those added by other
+ * editors, are kept in the Extra maps of the objects they were found in, so
+ * that they are written back unchanged.
+ */
+type Project struct {
+	Targets    []*Target  `json:"targets"`
+	Monitors   []*Monitor `json:"monitors"`
+	Extensions []string   `json:"extensions"`
+	Meta       Meta       `json:"meta"`
+
+	Extra map[string] json.RawMessage `json:"-"`
+}
+
+/* Meta holds information about the program that saved a project.
+ */
+type Meta struct {
+	Semver string `json:"semver"`
+	VM     string `json:"vm"`
+	Agent  string `json:"agent"`
+	Origin string `json:"origin,omitempty"`
+
+	Extra map[string] json.RawMessage `json:"-"`
+}
+
+/* UnmarshalJSON decodes the meta object of a project.
+ */
+func (meta *Meta) UnmarshalJSON (data []byte) (err error) {
+	type metaJSON Meta
+	decoded := metaJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+	*meta = Meta(decoded)
+	meta.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes the meta object of a project.
+ */
+func (meta Meta) MarshalJSON () (data []byte, err error) {
+	type metaJSON Meta
+	return marshalExtra(metaJSON(meta), meta.Extra)
+}
+
+/* Parse parses a project.json file.
+ */
+func Parse (data []byte) (project *Project, err error) {
+	project = &Project { }
+	err = json.Unmarshal(data, project)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse project: %v", err)
+	}
+	if len(project.Targets) == 0 {
+		return nil, fmt.Errorf("cannot parse project: no targets")
+	}
+	return
+}
+
+/* Marshal converts the project back into the contents of a project.json file.
+ */
+func (project *Project) Marshal () (data []byte, err error) {
+	return marshal(project)
+}
+
+/* UnmarshalJSON decodes the project.
+ */
+func (project *Project) UnmarshalJSON (data []byte) (err error) {
+	type projectJSON Project
+	decoded := projectJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+	*project = Project(decoded)
+	for index, target := range project.Targets {
+		if target == nil { return fmt.Errorf("target %d is null", index) }
+	}
+	for index, monitor := range project.Monitors {
+		if monitor == nil { return fmt.Errorf("monitor %d is null", index) }
+	}
+	project.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes the project, making sure that empty lists are written as
+ * empty arrays instead of null.
+ */
+func (project *Project) MarshalJSON () (data []byte, err error) {
+	type projectJSON Project
+	encoded := projectJSON(*project)
+	if encoded.Targets    == nil { encoded.Targets    = []*Target  { } }
+	if encoded.Monitors   == nil { encoded.Monitors   = []*Monitor { } }
+	if encoded.Extensions == nil { encoded.Extensions = []string   { } }
+	return marshalExtra(encoded, project.Extra)
+}
+
+/* Stage returns the stage target of the project, or nil if there is none.
+ */
+func (project *Project) Stage () (stage *Target) {
+	for _, target := range project.Targets {
+		if target.IsStage { return target }
+	}
+	return
+}
+
+/* Sprites returns all targets in the project that are not the stage, in the
+ * order that they are stored.
+ */
+func (project *Project) Sprites () (sprites []*Target) {
+	for _, target := range project.Targets {
+		if !target.IsStage {
+			sprites = append(sprites, target)
+		}
+	}
+	return
+}
+
+/* Target returns the target with the specified name, or nil if there is none.
+ * The stage is always named "Stage".
+ */
+func (project *Project) Target (name string) (target *Target) {
+	for _, target := range project.Targets {
+		if target.Name == name { return target }
+	}
+	return
+}
+
+/* CloudVariables returns the cloud variables declared by the project, sorted by
+ * name. Scratch only allows cloud variables on the stage, and their names
+ * start with the cloud symbol.
+ */
+func (project *Project) CloudVariables () (variables []*Variable) {
+	stage := project.Stage()
+	if stage == nil { return }
+	
+	for _, id := range SortedKeys(stage.Variables) {
+		variable := stage.Variables[id]
+		if variable.IsCloud && strings.HasPrefix(variable.Name, CloudSymbol) {
+			variables = append(variables, variable)
+		}
+	}
+	sort.SliceStable(variables, func (left, right int) bool {
+		return variables[left].Name < variables[right].Name
+	})
+	return
+}
+
+/* SortedKeys returns the keys of a map in sorted order, so that iteration over
+ * the items of a target is deterministic.
+ */
+func SortedKeys [T any] (items map[string] T) (keys []string) {
+	keys = make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,172 @@
+package sb3
+
+import "os"
+import "fmt"
+import "bytes"
+import "strings"
+import "testing"
+import "path/filepath"
+import "encoding/json"
+
+/* decodeAny decodes JSON into plain Go values, keeping numbers as they were
+ * written so that nothing is lost when comparing.
+ */
+func decodeAny (test *testing.T, data []byte) (value any) {
+	test.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err != nil { test.Fatal(err) }
+	return
+}
+
+/* difference returns a description of the first place where two decoded JSON
+ * values differ, or a blank string if they are the same.
+ */
+func difference (path string, expected, actual any) (description string) {
+	switch expected := expected.(type) {
+	case map[string] any:
+		actual, ok := actual.(map[string] any)
+		if !ok { break }
+		for _, key := range SortedKeys(expected) {
+			if _, ok := actual[key]; !ok {
+				return fmt.Sprintf("%s.%s is missing", path, key)
+			}
+			description = difference(path + "." + key, expected[key], actual[key])
+			if description != "" { return }
+		}
+		for _, key := range SortedKeys(actual) {
+			if _, ok := expected[key]; !ok {
+				return fmt.Sprintf("%s.%s was added", path, key)
+			}
+		}
+		return ""
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok || len(actual) != len(expected) { break }
+		for index := range expected {
+			description = difference (
+				fmt.Sprintf("%s[%d]", path, index),
+				expected[index], actual[index])
+			if description != "" { return }
+		}
+		return ""
+	case json.Number:
+		// numbers are compared by value, because 48 and 48.0 are the same
+		actual, ok := actual.(json.Number)
+		if !ok { break }
+		expectedFloat, _ := expected.Float64()
+		actualFloat,   _ := actual.Float64()
+		if expectedFloat == actualFloat { return "" }
+	default:
+		if expected == actual { return "" }
+	}
+	return fmt.Sprintf("%s is %v, expected %v", path, actual, expected)
+}
+
+func TestRoundTrip (test *testing.T) {
+	paths, err := filepath.Glob("testdata/*.json")
+	if err != nil { test.Fatal(err) }
+	if len(paths) == 0 { test.Fatal("no fixtures found") }
+
+	for _, path := range paths {
+		test.Run(filepath.Base(path), func (test *testing.T) {
+			original, err := os.ReadFile(path)
+			if err != nil { test.Fatal(err) }
+			project, err := Parse(original)
+			if err != nil { test.Fatal(err) }
+			written, err := project.Marshal()
+			if err != nil { test.Fatal(err) }
+
+			description := difference (
+				"project",
+				decodeAny(test, original),
+				decodeAny(test, written))
+			if description != "" { test.Fatal(description) }
+
+			// writing the project again must give the same result
+			reparsed, err := Parse(written)
+			if err != nil { test.Fatal(err) }
+			rewritten, err := reparsed.Marshal()
+			if err != nil { test.Fatal(err) }
+			if !bytes.Equal(written, rewritten) {
+				test.Fatalf("project changed when written again:\n%s\n%s", written, rewritten)
+			}
+		})
+	}
+}
+
+func TestExtraKeys (test *testing.T) {
+	original, err := os.ReadFile("testdata/turbowarp.json")
+	if err != nil { test.Fatal(err) }
+	project, err := Parse(original)
+	if err != nil { test.Fatal(err) }
+
+	expectExtra := func (where string, extra map[string] json.RawMessage, key string) {
+		test.Helper()
+		if _, ok := extra[key]; !ok {
+			test.Fatalf("%s has no extra key %s, has %v", where, key, extra)
+		}
+	}
+	expectExtra("project", project.Extra, "extensionURLs")
+	expectExtra("meta", project.Meta.Extra, "platform")
+	expectExtra("monitor", project.Monitors[0].Extra, "color")
+
+	sprite := project.Target("Sprite1")
+	expectExtra("sprite", sprite.Extra, "targetPaneOrder")
+	expectExtra("costume", sprite.Costumes[0].Extra, "customKey")
+	expectExtra("block", sprite.Blocks["fetch"].Extra, "editorState")
+	expectExtra("mutation", sprite.Blocks["fetch"].Mutation.Extra, "blockInfo")
+	expectExtra("mutation", sprite.Blocks["stop"].Mutation.Extra, "custom")
+
+	// known keys are never stored as extra keys, whatever their case
+	if _, ok := sprite.Blocks["fetch"].Extra["opcode"]; ok {
+		test.Fatal("opcode is stored as an extra key")
+	}
+	block := &Block { }
+	err = json.Unmarshal([]byte(`{"OPCODE":"x","topLevel":true}`), block)
+	if err != nil { test.Fatal(err) }
+	if block.Opcode != "x" || len(block.Extra) != 0 {
+		test.Fatalf("block decoded as %+v", block)
+	}
+
+	// extra keys never replace known ones
+	block.Extra = map[string] json.RawMessage { "opcode": json.RawMessage(`"y"`) }
+	data, err := block.MarshalJSON()
+	if err != nil { test.Fatal(err) }
+	decoded := decodeAny(test, data).(map[string] any)
+	if decoded["opcode"] != "x" {
+		test.Fatalf("extra key replaced opcode: %s", data)
+	}
+}
+
+func TestParseNullEntries (test *testing.T) {
+	sprite := func (key, value string) string {
+		return `{"targets":[{"isStage":true,"name":"Stage"},` +
+			`{"isStage":false,"name":"Cat","` + key + `":` + value + `}]}`
+	}
+	cases := []struct {
+		json     string
+		expected string
+	} {
+		{ `{"targets":[null]}`, "target 0 is null" },
+		{ `{"targets":[{"isStage":true}],"monitors":[null]}`, "monitor 0 is null" },
+		{ sprite("blocks", `{"a":null}`), "block a in Cat is null" },
+		{ sprite("variables", `{"a":null}`), "variable a in Cat is null" },
+		{ sprite("lists", `{"a":null}`), "list a in Cat is null" },
+		{ sprite("comments", `{"a":null}`), "comment a in Cat is null" },
+		{ sprite("costumes", `[null]`), "costume 0 in Cat is null" },
+		{ sprite("sounds", `[{"name":"pop"},null]`), "sound 1 in Cat is null" },
+	}
+
+	for _, testCase := range cases {
+		_, err := Parse([]byte(testCase.json))
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Errorf("parsing %s gave %v, expected %q", testCase.json, err, testCase.expected)
+		}
+	}
+
+	// empty maps and lists may still be null as a whole
+	_, err := Parse([]byte(sprite("variables", "null")))
+	if err != nil { test.Fatal(err) }
+}
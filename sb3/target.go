@@ -0,0 +1,408 @@
+package sb3
+
+import "fmt"
+import "sort"
+import "encoding/json"
+
+/* Target represents the stage or a sprite. Some fields only apply to the
+ * stage, and some only apply to sprites. Only the ones that apply are written
+ * when the target is encoded.
+ */
+type Target struct {
+	IsStage        bool
+	Name           string
+	Variables      map[string] *Variable
+	Lists          map[string] *List
+	Broadcasts     map[string] string
+	Blocks         map[string] *Block
+	Comments       map[string] *Comment
+	CurrentCostume int
+	Costumes       []*Costume
+	Sounds         []*Sound
+	Volume         float64
+	LayerOrder     int
+
+	// stage only
+	Tempo                float64
+	VideoTransparency    float64
+	VideoState           string
+	TextToSpeechLanguage string
+
+	// sprites only
+	Visible       bool
+	X             float64
+	Y             float64
+	Size          float64
+	Direction     float64
+	Draggable     bool
+	RotationStyle string
+
+	// Extra holds keys that are not listed above.
+	Extra map[string] json.RawMessage
+}
+
+/* targetJSON is the JSON encoding of a target. Fields that only apply to the
+ * stage or to sprites are pointers, so they can be left out.
+ */
+type targetJSON struct {
+	IsStage        bool                  `json:"isStage"`
+	Name           string                `json:"name"`
+	Variables      map[string] *Variable `json:"variables"`
+	Lists          map[string] *List     `json:"lists"`
+	Broadcasts     map[string] string    `json:"broadcasts"`
+	Blocks         map[string] *Block    `json:"blocks"`
+	Comments       map[string] *Comment  `json:"comments"`
+	CurrentCostume int                   `json:"currentCostume"`
+	Costumes       []*Costume            `json:"costumes"`
+	Sounds         []*Sound              `json:"sounds"`
+	Volume         float64               `json:"volume"`
+	LayerOrder     int                   `json:"layerOrder"`
+
+	Tempo                *float64        `json:"tempo,omitempty"`
+	VideoTransparency    *float64        `json:"videoTransparency,omitempty"`
+	VideoState           *string         `json:"videoState,omitempty"`
+	TextToSpeechLanguage json.RawMessage `json:"textToSpeechLanguage,omitempty"`
+
+	Visible       *bool    `json:"visible,omitempty"`
+	X             *float64 `json:"x,omitempty"`
+	Y             *float64 `json:"y,omitempty"`
+	Size          *float64 `json:"size,omitempty"`
+	Direction     *float64 `json:"direction,omitempty"`
+	Draggable     *bool    `json:"draggable,omitempty"`
+	RotationStyle *string  `json:"rotationStyle,omitempty"`
+}
+
+/* UnmarshalJSON decodes a target.
+ */
+func (target *Target) UnmarshalJSON (data []byte) (err error) {
+	decoded := targetJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+
+	*target = Target {
+		IsStage:        decoded.IsStage,
+		Name:           decoded.Name,
+		Variables:      decoded.Variables,
+		Lists:          decoded.Lists,
+		Broadcasts:     decoded.Broadcasts,
+		Blocks:         decoded.Blocks,
+		Comments:       decoded.Comments,
+		CurrentCostume: decoded.CurrentCostume,
+		Costumes:       decoded.Costumes,
+		Sounds:         decoded.Sounds,
+		Volume:         decoded.Volume,
+		LayerOrder:     decoded.LayerOrder,
+	}
+	target.makeMaps()
+	target.Extra, err = decodeExtra(data, decoded)
+	if err != nil { return }
+
+	if decoded.Tempo             != nil { target.Tempo             = *decoded.Tempo             }
+	if decoded.VideoTransparency != nil { target.VideoTransparency = *decoded.VideoTransparency }
+	if decoded.VideoState        != nil { target.VideoState        = *decoded.VideoState        }
+	if decoded.Visible           != nil { target.Visible           = *decoded.Visible           }
+	if decoded.X                 != nil { target.X                 = *decoded.X                 }
+	if decoded.Y                 != nil { target.Y                 = *decoded.Y                 }
+	if decoded.Size              != nil { target.Size              = *decoded.Size              }
+	if decoded.Direction         != nil { target.Direction         = *decoded.Direction         }
+	if decoded.Draggable         != nil { target.Draggable         = *decoded.Draggable         }
+	if decoded.RotationStyle     != nil { target.RotationStyle     = *decoded.RotationStyle     }
+	
+	if len(decoded.TextToSpeechLanguage) > 0 {
+		target.TextToSpeechLanguage, err = decodeNullableString (
+			decoded.TextToSpeechLanguage)
+		if err != nil { return }
+	}
+
+	// the ID of each item is only stored as its key, so it is copied into
+	// the item itself for convenience
+	for id, block := range target.Blocks {
+		if block == nil {
+			return fmt.Errorf("block %s in %s is null", id, target.Name)
+		}
+		block.ID = id
+	}
+	for id, variable := range target.Variables {
+		if variable == nil {
+			return fmt.Errorf("variable %s in %s is null", id, target.Name)
+		}
+		variable.ID = id
+	}
+	for id, list := range target.Lists {
+		if list == nil {
+			return fmt.Errorf("list %s in %s is null", id, target.Name)
+		}
+		list.ID = id
+	}
+	for id, comment := range target.Comments {
+		if comment == nil {
+			return fmt.Errorf("comment %s in %s is null", id, target.Name)
+		}
+		comment.ID = id
+	}
+	for index, costume := range target.Costumes {
+		if costume == nil {
+			return fmt.Errorf("costume %d in %s is null", index, target.Name)
+		}
+	}
+	for index, sound := range target.Sounds {
+		if sound == nil {
+			return fmt.Errorf("sound %d in %s is null", index, target.Name)
+		}
+	}
+	return
+}
+
+/* MarshalJSON encodes a target.
+ */
+func (target *Target) MarshalJSON () (data []byte, err error) {
+	copied := *target
+	copied.makeMaps()
+	encoded := targetJSON {
+		IsStage:        copied.IsStage,
+		Name:           copied.Name,
+		Variables:      copied.Variables,
+		Lists:          copied.Lists,
+		Broadcasts:     copied.Broadcasts,
+		Blocks:         copied.Blocks,
+		Comments:       copied.Comments,
+		CurrentCostume: copied.CurrentCostume,
+		Costumes:       copied.Costumes,
+		Sounds:         copied.Sounds,
+		Volume:         copied.Volume,
+		LayerOrder:     copied.LayerOrder,
+	}
+	if encoded.Costumes == nil { encoded.Costumes = []*Costume { } }
+	if encoded.Sounds   == nil { encoded.Sounds   = []*Sound   { } }
+
+	if copied.IsStage {
+		encoded.Tempo             = &copied.Tempo
+		encoded.VideoTransparency = &copied.VideoTransparency
+		encoded.VideoState        = &copied.VideoState
+		encoded.TextToSpeechLanguage, err = marshal (
+			nullableString(copied.TextToSpeechLanguage))
+		if err != nil { return }
+	} else {
+		encoded.Visible       = &copied.Visible
+		encoded.X             = &copied.X
+		encoded.Y             = &copied.Y
+		encoded.Size          = &copied.Size
+		encoded.Direction     = &copied.Direction
+		encoded.Draggable     = &copied.Draggable
+		encoded.RotationStyle = &copied.RotationStyle
+	}
+	
+	return marshalExtra(encoded, copied.Extra)
+}
+
+/* makeMaps replaces nil maps with empty ones.
+ */
+func (target *Target) makeMaps () {
+	if target.Variables  == nil { target.Variables  = map[string] *Variable { } }
+	if target.Lists      == nil { target.Lists      = map[string] *List     { } }
+	if target.Broadcasts == nil { target.Broadcasts = map[string] string    { } }
+	if target.Blocks     == nil { target.Blocks     = map[string] *Block    { } }
+	if target.Comments   == nil { target.Comments   = map[string] *Comment  { } }
+}
+
+/* Scripts returns the IDs of the top level blocks in the target, which are the
+ * first blocks of each script. They are sorted by position from top to bottom,
+ * then left to right, so that the order is deterministic.
+ */
+func (target *Target) Scripts () (ids []string) {
+	for id, block := range target.Blocks {
+		if block.TopLevel { ids = append(ids, id) }
+	}
+
+	sort.Slice(ids, func (left, right int) bool {
+		leftBlock  := target.Blocks[ids[left]]
+		rightBlock := target.Blocks[ids[right]]
+		if leftBlock.Y != rightBlock.Y { return leftBlock.Y < rightBlock.Y }
+		if leftBlock.X != rightBlock.X { return leftBlock.X < rightBlock.X }
+		return ids[left] < ids[right]
+	})
+	return
+}
+
+/* Stack returns the IDs of a block and all of the blocks that come after it,
+ * following their next links.
+ */
+func (target *Target) Stack (id string) (ids []string) {
+	visited := map[string] bool { }
+	for id != "" && !visited[id] {
+		block := target.Blocks[id]
+		if block == nil { break }
+		visited[id] = true
+		ids = append(ids, id)
+		id = block.Next
+	}
+	return
+}
+
+/* Costume returns the costume with the specified name, or nil if there is none.
+ */
+func (target *Target) Costume (name string) (costume *Costume) {
+	for _, costume := range target.Costumes {
+		if costume.Name == name { return costume }
+	}
+	return
+}
+
+/* Sound returns the sound with the specified name, or nil if there is none.
+ */
+func (target *Target) Sound (name string) (sound *Sound) {
+	for _, sound := range target.Sounds {
+		if sound.Name == name { return sound }
+	}
+	return
+}
+
+/* Variable represents a variable. Its value is either a string, a json.Number,
+ * or a bool. Cloud variables belong to the stage, and their names start with
+ * the cloud symbol.
+ */
+type Variable struct {
+	ID      string
+	Name    string
+	Value   any
+	IsCloud bool
+}
+
+/* UnmarshalJSON decodes a variable, which is stored as an array containing its
+ * name, its value, and whether it is a cloud variable.
+ */
+func (variable *Variable) UnmarshalJSON (data []byte) (err error) {
+	array, err := decodeArray(data)
+	if err != nil { return }
+	if len(array) < 2 {
+		return fmt.Errorf("variable has %d elements, expected 2", len(array))
+	}
+
+	err = json.Unmarshal(array[0], &variable.Name)
+	if err != nil { return }
+	variable.Value, err = decodeValue(array[1])
+	if err != nil { return }
+	if len(array) > 2 {
+		err = json.Unmarshal(array[2], &variable.IsCloud)
+	}
+	return
+}
+
+/* MarshalJSON encodes a variable.
+ */
+func (variable *Variable) MarshalJSON () (data []byte, err error) {
+	array := []any { variable.Name, variable.Value }
+	if variable.IsCloud {
+		array = append(array, true)
+	}
+	return marshal(array)
+}
+
+/* List represents a list. Each item is either a string, a json.Number, or a
+ * bool.
+ */
+type List struct {
+	ID     string
+	Name   string
+	Values []any
+}
+
+/* UnmarshalJSON decodes a list, which is stored as an array containing its name
+ * and its contents.
+ */
+func (list *List) UnmarshalJSON (data []byte) (err error) {
+	array, err := decodeArray(data)
+	if err != nil { return }
+	if len(array) < 2 {
+		return fmt.Errorf("list has %d elements, expected 2", len(array))
+	}
+
+	err = json.Unmarshal(array[0], &list.Name)
+	if err != nil { return }
+	values, err := decodeValue(array[1])
+	if err != nil { return }
+	
+	list.Values, _ = values.([]any)
+	if list.Values == nil && values != nil {
+		return fmt.Errorf("contents of list %s are not an array", list.Name)
+	}
+	return
+}
+
+/* MarshalJSON encodes a list.
+ */
+func (list *List) MarshalJSON () (data []byte, err error) {
+	values := list.Values
+	if values == nil {
+		values = []any { }
+	}
+	return marshal([]any { list.Name, values })
+}
+
+/* Comment represents a comment in the code area. If BlockID is not blank, the
+ * comment is attached to that block.
+ */
+type Comment struct {
+	ID        string
+	BlockID   string
+	X         float64
+	Y         float64
+	Width     float64
+	Height    float64
+	Minimized bool
+	Text      string
+
+	// Extra holds keys that are not listed above.
+	Extra map[string] json.RawMessage
+}
+
+/* commentJSON is the JSON encoding of a comment.
+ */
+type commentJSON struct {
+	BlockID   *string `json:"blockId"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	Minimized bool    `json:"minimized"`
+	Text      string  `json:"text"`
+}
+
+/* UnmarshalJSON decodes a comment.
+ */
+func (comment *Comment) UnmarshalJSON (data []byte) (err error) {
+	decoded := commentJSON { }
+	err = json.Unmarshal(data, &decoded)
+	if err != nil { return }
+
+	*comment = Comment {
+		X:         decoded.X,
+		Y:         decoded.Y,
+		Width:     decoded.Width,
+		Height:    decoded.Height,
+		Minimized: decoded.Minimized,
+		Text:      decoded.Text,
+	}
+	if decoded.BlockID != nil {
+		comment.BlockID = *decoded.BlockID
+	}
+	comment.Extra, err = decodeExtra(data, decoded)
+	return
+}
+
+/* MarshalJSON encodes a comment.
+ */
+func (comment *Comment) MarshalJSON () (data []byte, err error) {
+	encoded := commentJSON {
+		X:         comment.X,
+		Y:         comment.Y,
+		Width:     comment.Width,
+		Height:    comment.Height,
+		Minimized: comment.Minimized,
+		Text:      comment.Text,
+	}
+	if comment.BlockID != "" {
+		encoded.BlockID = &comment.BlockID
+	}
+	return marshalExtra(encoded, comment.Extra)
+}
@@ -0,0 +1,453 @@
+package sb3
+
+import "fmt"
+import "strings"
+
+// reservedNames holds names that sprites cannot have, because Scratch uses
+// them for special menu items.
+var reservedNames = map[string] bool {
+	"_mouse_":  true,
+	"_stage_":  true,
+	"_edge_":   true,
+	"_myself_": true,
+	"_random_": true,
+}
+
+/* Validate checks that the project follows the rules that Scratch expects
+ * projects to follow, such as every block link pointing to a block that exists
+ * and links back, every variable reference pointing to a variable that exists,
+ * and every target having a costume. All problems that are found are described
+ * in the returned error.
+ */
+func (project *Project) Validate () (err error) {
+	validator := validator { project: project }
+	validator.validate()
+	if len(validator.problems) > 0 {
+		return fmt.Errorf (
+			"invalid project: %s",
+			strings.Join(validator.problems, "; "))
+	}
+	return nil
+}
+
+/* validator holds the state of a project that is being validated.
+ */
+type validator struct {
+	project  *Project
+	targets  []*Target
+	stage    *Target
+	problems []string
+}
+
+/* problem records a problem with the project.
+ */
+func (validator *validator) problem (format string, values ...any) {
+	validator.problems = append(validator.problems, fmt.Sprintf(format, values...))
+}
+
+/* validate checks the entire project.
+ */
+func (validator *validator) validate () {
+	project := validator.project
+	if !strings.HasPrefix(project.Meta.Semver, "3.") {
+		validator.problem("semver %q is not 3.x", project.Meta.Semver)
+	}
+
+	stages := 0
+	names  := map[string] bool { }
+	for index, target := range project.Targets {
+		if target == nil {
+			validator.problem("target %d is null", index)
+			continue
+		}
+		validator.targets = append(validator.targets, target)
+
+		if target.IsStage {
+			stages ++
+			validator.stage = target
+			if target.Name != "Stage" {
+				validator.problem("stage is named %q", target.Name)
+			}
+			continue
+		}
+
+		switch {
+		case target.Name == "":
+			validator.problem("sprite has no name")
+		case target.Name == "Stage" || reservedNames[target.Name]:
+			validator.problem("sprite has reserved name %q", target.Name)
+		case names[target.Name]:
+			validator.problem("there is more than one sprite named %q", target.Name)
+		}
+		names[target.Name] = true
+	}
+	if stages != 1 {
+		validator.problem("project has %d stages, expected 1", stages)
+	}
+
+	validator.validateIDs()
+	for _, target := range validator.targets {
+		validator.validateAssets(target)
+		validator.validateVariables(target)
+		for _, id := range SortedKeys(target.Blocks) {
+			validator.validateBlock(target, id)
+		}
+		for _, id := range SortedKeys(target.Comments) {
+			comment := target.Comments[id]
+			if comment == nil {
+				validator.problem("%s: comment %s is null", target.Name, id)
+				continue
+			}
+			if comment.BlockID != "" && target.Blocks[comment.BlockID] == nil {
+				validator.problem (
+					"%s: comment %s is attached to missing block %s",
+					target.Name, id, comment.BlockID)
+			}
+		}
+	}
+}
+
+/* validateIDs checks that variable and list IDs are not used by more than one
+ * target.
+ */
+func (validator *validator) validateIDs () {
+	owners := map[string] string { }
+	check  := func (target *Target, id string) {
+		if owner, ok := owners[id]; ok {
+			validator.problem (
+				"%s: ID %s is already used by %s",
+				target.Name, id, owner)
+			return
+		}
+		owners[id] = target.Name
+	}
+
+	for _, target := range validator.targets {
+		for _, id := range SortedKeys(target.Variables) { check(target, id) }
+		for _, id := range SortedKeys(target.Lists)     { check(target, id) }
+	}
+}
+
+/* validateAssets checks the costumes and sounds of a target.
+ */
+func (validator *validator) validateAssets (target *Target) {
+	if len(target.Costumes) == 0 {
+		validator.problem("%s: target has no costumes", target.Name)
+	} else if target.CurrentCostume < 0 ||
+		target.CurrentCostume >= len(target.Costumes) {
+		validator.problem (
+			"%s: current costume %d is out of range",
+			target.Name, target.CurrentCostume)
+	}
+
+	for index, costume := range target.Costumes {
+		if costume == nil {
+			validator.problem("%s: costume %d is null", target.Name, index)
+			continue
+		}
+		validator.validateAsset (
+			target, "costume", costume.Name,
+			costume.AssetID, costume.DataFormat, costume.FileName())
+	}
+	for index, sound := range target.Sounds {
+		if sound == nil {
+			validator.problem("%s: sound %d is null", target.Name, index)
+			continue
+		}
+		validator.validateAsset (
+			target, "sound", sound.Name,
+			sound.AssetID, sound.DataFormat, sound.FileName())
+	}
+}
+
+/* validateAsset checks a costume or sound.
+ */
+func (validator *validator) validateAsset (
+	target     *Target,
+	kind       string,
+	name       string,
+	assetID    string,
+	dataFormat string,
+	fileName   string,
+) {
+	switch {
+	case name == "":
+		validator.problem("%s: %s has no name", target.Name, kind)
+	case assetID == "":
+		validator.problem("%s: %s %s has no asset ID", target.Name, kind, name)
+	case dataFormat == "":
+		validator.problem("%s: %s %s has no data format", target.Name, kind, name)
+	case !strings.HasPrefix(fileName, assetID):
+		validator.problem (
+			"%s: %s %s has asset ID %s but file %s",
+			target.Name, kind, name, assetID, fileName)
+	}
+}
+
+/* validateVariables checks the variables and lists of a target. Names must be
+ * unique, sprites cannot have variables with the same names as the stage's
+ * variables, and only the stage can have cloud variables.
+ */
+func (validator *validator) validateVariables (target *Target) {
+	variables := map[string] bool { }
+	for _, id := range SortedKeys(target.Variables) {
+		variable := target.Variables[id]
+		if variable == nil {
+			validator.problem("%s: variable %s is null", target.Name, id)
+			continue
+		}
+		if variables[variable.Name] {
+			validator.problem (
+				"%s: there is more than one variable named %q",
+				target.Name, variable.Name)
+		}
+		variables[variable.Name] = true
+
+		if variable.IsCloud && !target.IsStage {
+			validator.problem (
+				"%s: variable %q is a cloud variable, but only the " +
+				"stage can have cloud variables",
+				target.Name, variable.Name)
+		}
+		if variable.IsCloud && !strings.HasPrefix(variable.Name, CloudSymbol) {
+			validator.problem (
+				"%s: cloud variable %q does not start with %q",
+				target.Name, variable.Name, CloudSymbol)
+		}
+		if !target.IsStage && validator.stage != nil &&
+			findVariable(validator.stage, variable.Name) {
+			validator.problem (
+				"%s: variable %q has the same name as a global variable",
+				target.Name, variable.Name)
+		}
+	}
+
+	lists := map[string] bool { }
+	for _, id := range SortedKeys(target.Lists) {
+		list := target.Lists[id]
+		if list == nil {
+			validator.problem("%s: list %s is null", target.Name, id)
+			continue
+		}
+		if lists[list.Name] {
+			validator.problem (
+				"%s: there is more than one list named %q",
+				target.Name, list.Name)
+		}
+		lists[list.Name] = true
+
+		if !target.IsStage && validator.stage != nil &&
+			findList(validator.stage, list.Name) {
+			validator.problem (
+				"%s: list %q has the same name as a global list",
+				target.Name, list.Name)
+		}
+	}
+}
+
+/* findVariable returns whether a target has a variable with the specified
+ * name.
+ */
+func findVariable (target *Target, name string) (found bool) {
+	for _, variable := range target.Variables {
+		if variable != nil && variable.Name == name { return true }
+	}
+	return
+}
+
+/* findList returns whether a target has a list with the specified name.
+ */
+func findList (target *Target, name string) (found bool) {
+	for _, list := range target.Lists {
+		if list != nil && list.Name == name { return true }
+	}
+	return
+}
+
+/* validateBlock checks the links and references of a block.
+ */
+func (validator *validator) validateBlock (target *Target, id string) {
+	block := target.Blocks[id]
+	where := fmt.Sprintf("%s: block %s", target.Name, id)
+	if block == nil {
+		validator.problem("%s is null", where)
+		return
+	}
+	if block.Primitive != nil {
+		validator.validatePrimitive(target, where, block.Primitive)
+		return
+	}
+
+	if block.Opcode == "" {
+		validator.problem("%s has no opcode", where)
+	}
+	if block.TopLevel && block.Parent != "" {
+		validator.problem("%s is top level but has parent %s", where, block.Parent)
+	}
+	if !block.TopLevel && block.Parent == "" {
+		validator.problem("%s is not top level but has no parent", where)
+	}
+	if block.Parent != "" && target.Blocks[block.Parent] == nil {
+		validator.problem("%s has missing parent %s", where, block.Parent)
+	}
+	if block.Next != "" {
+		next := target.Blocks[block.Next]
+		switch {
+		case next == nil:
+			validator.problem("%s has missing next block %s", where, block.Next)
+		case next.Parent != id:
+			validator.problem (
+				"%s has next block %s, whose parent is %q",
+				where, block.Next, next.Parent)
+		}
+	}
+
+	for _, name := range SortedKeys(block.Inputs) {
+		input := block.Inputs[name]
+		for _, value := range []InputValue { input.Block, input.Shadow } {
+			if value.Primitive != nil {
+				validator.validatePrimitive(target, where, value.Primitive)
+			}
+			if value.BlockID == "" { continue }
+
+			inner := target.Blocks[value.BlockID]
+			switch {
+			case inner == nil:
+				validator.problem (
+					"%s has missing block %s in input %s",
+					where, value.BlockID, name)
+			case inner.Primitive == nil && inner.Parent != id:
+				validator.problem (
+					"%s has block %s in input %s, whose parent is %q",
+					where, value.BlockID, name, inner.Parent)
+			}
+		}
+	}
+
+	for _, name := range SortedKeys(block.Fields) {
+		field := block.Fields[name]
+		if field.ID == "" { continue }
+		switch name {
+		case "VARIABLE":
+			if !validator.hasVariable(target, field.ID) {
+				validator.problem (
+					"%s refers to missing variable %s",
+					where, field.ID)
+			}
+		case "LIST":
+			if !validator.hasList(target, field.ID) {
+				validator.problem("%s refers to missing list %s", where, field.ID)
+			}
+		case "BROADCAST_OPTION":
+			if !validator.hasBroadcast(field.ID) {
+				validator.problem (
+					"%s refers to missing broadcast %s",
+					where, field.ID)
+			}
+		}
+	}
+
+	switch block.Opcode {
+	case "procedures_prototype", "procedures_call":
+		validator.validateMutation(target, where, block)
+	}
+}
+
+/* validatePrimitive checks that a primitive refers to a variable, list, or
+ * broadcast that exists.
+ */
+func (validator *validator) validatePrimitive (
+	target    *Target,
+	where     string,
+	primitive *Primitive,
+) {
+	switch primitive.Type {
+	case PrimitiveTypeVariable:
+		if !validator.hasVariable(target, primitive.ID) {
+			validator.problem (
+				"%s refers to missing variable %s",
+				where, primitive.ID)
+		}
+	case PrimitiveTypeList:
+		if !validator.hasList(target, primitive.ID) {
+			validator.problem("%s refers to missing list %s", where, primitive.ID)
+		}
+	case PrimitiveTypeBroadcast:
+		if !validator.hasBroadcast(primitive.ID) {
+			validator.problem (
+				"%s refers to missing broadcast %s",
+				where, primitive.ID)
+		}
+	}
+}
+
+/* validateMutation checks the mutation of a custom block prototype or call.
+ * The amount of argument IDs must match the amount of arguments in its
+ * proccode, and calls must refer to a custom block defined in the target.
+ */
+func (validator *validator) validateMutation (
+	target *Target,
+	where  string,
+	block  *Block,
+) {
+	if block.Mutation == nil {
+		validator.problem("%s has no mutation", where)
+		return
+	}
+
+	ids, err := block.Mutation.ArgumentIDList()
+	if err != nil {
+		validator.problem("%s has invalid argument IDs: %v", where, err)
+		return
+	}
+	arguments := strings.Count(block.Mutation.ProcCode, "%s") +
+		strings.Count(block.Mutation.ProcCode, "%n") +
+		strings.Count(block.Mutation.ProcCode, "%b")
+	if len(ids) != arguments {
+		validator.problem (
+			"%s has %d argument IDs, but its proccode has %d arguments",
+			where, len(ids), arguments)
+	}
+
+	if block.Opcode != "procedures_call" { return }
+	for _, other := range target.Blocks {
+		if other != nil &&
+			other.Opcode == "procedures_prototype" &&
+			other.Mutation != nil &&
+			other.Mutation.ProcCode == block.Mutation.ProcCode {
+			return
+		}
+	}
+	validator.problem (
+		"%s calls undefined custom block %q",
+		where, block.Mutation.ProcCode)
+}
+
+/* hasVariable returns whether a variable with the specified ID is accessible
+ * from a target.
+ */
+func (validator *validator) hasVariable (target *Target, id string) (has bool) {
+	if _, ok := target.Variables[id]; ok { return true }
+	if validator.stage == nil { return false }
+	_, ok := validator.stage.Variables[id]
+	return ok
+}
+
+/* hasList returns whether a list with the specified ID is accessible from a
+ * target.
+ */
+func (validator *validator) hasList (target *Target, id string) (has bool) {
+	if _, ok := target.Lists[id]; ok { return true }
+	if validator.stage == nil { return false }
+	_, ok := validator.stage.Lists[id]
+	return ok
+}
+
+/* hasBroadcast returns whether a broadcast with the specified ID exists.
+ * Broadcasts are always stored on the stage.
+ */
+func (validator *validator) hasBroadcast (id string) (has bool) {
+	if validator.stage == nil { return false }
+	_, ok := validator.stage.Broadcasts[id]
+	return ok
+}
@@ -0,0 +1,81 @@
+package sb3
+
+import "os"
+import "strings"
+import "testing"
+import "encoding/json"
+
+func TestValidateNullEntries (test *testing.T) {
+	// null entries in project.json are reported when the project is parsed,
+	// before anything can dereference them
+	data, err := os.ReadFile("testdata/scratch.json")
+	if err != nil { test.Fatal(err) }
+	for _, testCase := range []struct {
+		target   int
+		key      string
+		expected string
+	} {
+		{ -1, "targets",   "target 2 is null" },
+		{ -1, "monitors",  "monitor 2 is null" },
+		{  1, "blocks",    "block z in Sprite1 is null" },
+		{  1, "variables", "variable z in Sprite1 is null" },
+		{  1, "lists",     "list z in Sprite1 is null" },
+		{  1, "comments",  "comment z in Sprite1 is null" },
+		{  1, "costumes",  "costume 1 in Sprite1 is null" },
+		{  0, "sounds",    "sound 1 in Stage is null" },
+	} {
+		project := decodeAny(test, data).(map[string] any)
+		object  := project
+		if testCase.target >= 0 {
+			object = project["targets"].([]any)[testCase.target].(map[string] any)
+		}
+		switch items := object[testCase.key].(type) {
+		case map[string] any: items["z"] = nil
+		case []any:           object[testCase.key] = append(items, nil)
+		}
+		changed, err := json.Marshal(project)
+		if err != nil { test.Fatal(err) }
+
+		parsed, err := Parse(changed)
+		if err == nil { err = parsed.Validate() }
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Errorf("null entry in %s gave %v, expected %q", testCase.key, err, testCase.expected)
+		}
+	}
+}
+
+func TestValidateNullItems (test *testing.T) {
+	// projects that are built in memory may still hold null entries, which
+	// are reported by Validate
+	data, err := os.ReadFile("testdata/scratch.json")
+	if err != nil { test.Fatal(err) }
+	project, err := Parse(data)
+	if err != nil { test.Fatal(err) }
+	err = project.Validate()
+	if err != nil { test.Fatal(err) }
+
+	sprite := project.Target("Sprite1")
+	sprite.Blocks["z"]       = nil
+	sprite.Variables["z"]    = nil
+	sprite.Lists["z"]        = nil
+	sprite.Comments["z"]     = nil
+	sprite.Costumes          = append(sprite.Costumes, nil)
+	project.Stage().Variables["y"] = nil
+	project.Targets          = append(project.Targets, nil)
+
+	err = project.Validate()
+	if err == nil { test.Fatal("project with null entries is valid") }
+	for _, problem := range []string {
+		"target 2 is null",
+		"Sprite1: block z is null",
+		"Sprite1: variable z is null",
+		"Sprite1: list z is null",
+		"Sprite1: comment z is null",
+		"Sprite1: costume 1 is null",
+		"Stage: variable y is null",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			test.Errorf("%q is not reported in: %v", problem, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package scratchblocks
+
+/* shape represents the shape of a block, which decides how it is written.
+ */
+type shape int
+
+const (
+	shapeStack shape = iota
+	shapeHat
+	shapeCap
+	shapeReporter
+	shapeBoolean
+
+	// shapeC is used for blocks with one substack, and shapeE for blocks
+	// with two.
+	shapeC
+	shapeE
+)
+
+/* blockSpec describes how a block is written. In the text, the names of inputs
+ * and fields are surrounded by curly braces, and are replaced by their values.
+ */
+type blockSpec struct {
+	text  string
+	shape shape
+}
+
+// specs holds the block specs of all blocks that come with Scratch, and of the
+// pen extension.
+var specs = map[string] blockSpec {
+	// motion
+	"motion_movesteps":        { "move {STEPS} steps", shapeStack },
+	"motion_turnright":        { "turn @turnRight {DEGREES} degrees", shapeStack },
+	"motion_turnleft":         { "turn @turnLeft {DEGREES} degrees", shapeStack },
+	"motion_goto":             { "go to {TO}", shapeStack },
+	"motion_gotoxy":           { "go to x: {X} y: {Y}", shapeStack },
+	"motion_glideto":          { "glide {SECS} secs to {TO}", shapeStack },
+	"motion_glidesecstoxy":    { "glide {SECS} secs to x: {X} y: {Y}", shapeStack },
+	"motion_pointindirection": { "point in direction {DIRECTION}", shapeStack },
+	"motion_pointtowards":     { "point towards {TOWARDS}", shapeStack },
+	"motion_changexby":        { "change x by {DX}", shapeStack },
+	"motion_setx":             { "set x to {X}", shapeStack },
+	"motion_changeyby":        { "change y by {DY}", shapeStack },
+	"motion_sety":             { "set y to {Y}", shapeStack },
+	"motion_ifonedgebounce":   { "if on edge, bounce", shapeStack },
+	"motion_setrotationstyle": { "set rotation style {STYLE}", shapeStack },
+	"motion_xposition":        { "x position", shapeReporter },
+	"motion_yposition":        { "y position", shapeReporter },
+	"motion_direction":        { "direction", shapeReporter },
+
+	// looks
+	"looks_sayforsecs":              { "say {MESSAGE} for {SECS} seconds", shapeStack },
+	"looks_say":                     { "say {MESSAGE}", shapeStack },
+	"looks_thinkforsecs":            { "think {MESSAGE} for {SECS} seconds", shapeStack },
+	"looks_think":                   { "think {MESSAGE}", shapeStack },
+	"looks_switchcostumeto":         { "switch costume to {COSTUME}", shapeStack },
+	"looks_nextcostume":             { "next costume", shapeStack },
+	"looks_switchbackdropto":        { "switch backdrop to {BACKDROP}", shapeStack },
+	"looks_switchbackdroptoandwait": { "switch backdrop to {BACKDROP} and wait", shapeStack },
+	"looks_nextbackdrop":            { "next backdrop", shapeStack },
+	"looks_changesizeby":            { "change size by {CHANGE}", shapeStack },
+	"looks_setsizeto":               { "set size to {SIZE} %", shapeStack },
+	"looks_changeeffectby":          { "change {EFFECT} effect by {CHANGE}", shapeStack },
+	"looks_seteffectto":             { "set {EFFECT} effect to {VALUE}", shapeStack },
+	"looks_cleargraphiceffects":     { "clear graphic effects", shapeStack },
+	"looks_show":                    { "show", shapeStack },
+	"looks_hide":                    { "hide", shapeStack },
+	"looks_gotofrontback":           { "go to {FRONT_BACK} layer", shapeStack },
+	"looks_goforwardbackwardlayers": { "go {FORWARD_BACKWARD} {NUM} layers", shapeStack },
+	"looks_costumenumbername":       { "costume {NUMBER_NAME}", shapeReporter },
+	"looks_backdropnumbername":      { "backdrop {NUMBER_NAME}", shapeReporter },
+	"looks_size":                    { "size", shapeReporter },
+
+	// sound
+	"sound_playuntildone":   { "play sound {SOUND_MENU} until done", shapeStack },
+	"sound_play":            { "start sound {SOUND_MENU}", shapeStack },
+	"sound_stopallsounds":   { "stop all sounds", shapeStack },
+	"sound_changeeffectby":  { "change {EFFECT} effect by {VALUE}", shapeStack },
+	"sound_seteffectto":     { "set {EFFECT} effect to {VALUE}", shapeStack },
+	"sound_cleareffects":    { "clear sound effects", shapeStack },
+	"sound_changevolumeby":  { "change volume by {VOLUME}", shapeStack },
+	"sound_setvolumeto":     { "set volume to {VOLUME} %", shapeStack },
+	"sound_volume":          { "volume", shapeReporter },
+
+	// events
+	"event_whenflagclicked":        { "when green flag clicked", shapeHat },
+	"event_whenkeypressed":         { "when {KEY_OPTION} key pressed", shapeHat },
+	"event_whenthisspriteclicked":  { "when this sprite clicked", shapeHat },
+	"event_whenstageclicked":       { "when stage clicked", shapeHat },
+	"event_whenbackdropswitchesto": { "when backdrop switches to {BACKDROP}", shapeHat },
+	"event_whengreaterthan":        { "when {WHENGREATERTHANMENU} > {VALUE}", shapeHat },
+	"event_whenbroadcastreceived":  { "when I receive {BROADCAST_OPTION}", shapeHat },
+	"event_broadcast":              { "broadcast {BROADCAST_INPUT}", shapeStack },
+	"event_broadcastandwait":       { "broadcast {BROADCAST_INPUT} and wait", shapeStack },
+
+	// control
+	"control_wait":              { "wait {DURATION} seconds", shapeStack },
+	"control_repeat":            { "repeat {TIMES}", shapeC },
+	"control_forever":           { "forever", shapeC },
+	"control_if":                { "if {CONDITION} then", shapeC },
+	"control_if_else":           { "if {CONDITION} then", shapeE },
+	"control_wait_until":        { "wait until {CONDITION}", shapeStack },
+	"control_repeat_until":      { "repeat until {CONDITION}", shapeC },
+	"control_while":             { "while {CONDITION}", shapeC },
+	"control_stop":              { "stop {STOP_OPTION}", shapeCap },
+	"control_start_as_clone":    { "when I start as a clone", shapeHat },
+	"control_create_clone_of":   { "create clone of {CLONE_OPTION}", shapeStack },
+	"control_delete_this_clone": { "delete this clone", shapeCap },
+
+	// sensing
+	"sensing_touchingobject":       { "touching {TOUCHINGOBJECTMENU}?", shapeBoolean },
+	"sensing_touchingcolor":        { "touching color {COLOR}?", shapeBoolean },
+	"sensing_coloristouchingcolor": { "color {COLOR} is touching {COLOR2}?", shapeBoolean },
+	"sensing_distanceto":           { "distance to {DISTANCETOMENU}", shapeReporter },
+	"sensing_askandwait":           { "ask {QUESTION} and wait", shapeStack },
+	"sensing_answer":               { "answer", shapeReporter },
+	"sensing_keypressed":           { "key {KEY_OPTION} pressed?", shapeBoolean },
+	"sensing_mousedown":            { "mouse down?", shapeBoolean },
+	"sensing_mousex":               { "mouse x", shapeReporter },
+	"sensing_mousey":               { "mouse y", shapeReporter },
+	"sensing_setdragmode":          { "set drag mode {DRAG_MODE}", shapeStack },
+	"sensing_loudness":             { "loudness", shapeReporter },
+	"sensing_timer":                { "timer", shapeReporter },
+	"sensing_resettimer":           { "reset timer", shapeStack },
+	"sensing_of":                   { "{PROPERTY} of {OBJECT}", shapeReporter },
+	"sensing_current":              { "current {CURRENTMENU}", shapeReporter },
+	"sensing_dayssince2000":        { "days since 2000", shapeReporter },
+	"sensing_username":             { "username", shapeReporter },
+
+	// operators
+	"operator_add":       { "{NUM1} + {NUM2}", shapeReporter },
+	"operator_subtract":  { "{NUM1} - {NUM2}", shapeReporter },
+	"operator_multiply":  { "{NUM1} * {NUM2}", shapeReporter },
+	"operator_divide":    { "{NUM1} / {NUM2}", shapeReporter },
+	"operator_random":    { "pick random {FROM} to {TO}", shapeReporter },
+	"operator_gt":        { "{OPERAND1} > {OPERAND2}", shapeBoolean },
+	"operator_lt":        { "{OPERAND1} < {OPERAND2}", shapeBoolean },
+	"operator_equals":    { "{OPERAND1} = {OPERAND2}", shapeBoolean },
+	"operator_and":       { "{OPERAND1} and {OPERAND2}", shapeBoolean },
+	"operator_or":        { "{OPERAND1} or {OPERAND2}", shapeBoolean },
+	"operator_not":       { "not {OPERAND}", shapeBoolean },
+	"operator_join":      { "join {STRING1} {STRING2}", shapeReporter },
+	"operator_letter_of": { "letter {LETTER} of {STRING}", shapeReporter },
+	"operator_length":    { "length of {STRING}", shapeReporter },
+	"operator_contains":  { "{STRING1} contains {STRING2}?", shapeBoolean },
+	"operator_mod":       { "{NUM1} mod {NUM2}", shapeReporter },
+	"operator_round":     { "round {NUM}", shapeReporter },
+	"operator_mathop":    { "{OPERATOR} of {NUM}", shapeReporter },
+
+	// variables and lists
+	"data_setvariableto":     { "set {VARIABLE} to {VALUE}", shapeStack },
+	"data_changevariableby":  { "change {VARIABLE} by {VALUE}", shapeStack },
+	"data_showvariable":      { "show variable {VARIABLE}", shapeStack },
+	"data_hidevariable":      { "hide variable {VARIABLE}", shapeStack },
+	"data_addtolist":         { "add {ITEM} to {LIST}", shapeStack },
+	"data_deleteoflist":      { "delete {INDEX} of {LIST}", shapeStack },
+	"data_deletealloflist":   { "delete all of {LIST}", shapeStack },
+	"data_insertatlist":      { "insert {ITEM} at {INDEX} of {LIST}", shapeStack },
+	"data_replaceitemoflist": { "replace item {INDEX} of {LIST} with {ITEM}", shapeStack },
+	"data_itemoflist":        { "item {INDEX} of {LIST}", shapeReporter },
+	"data_itemnumoflist":     { "item # of {ITEM} in {LIST}", shapeReporter },
+	"data_lengthoflist":      { "length of {LIST}", shapeReporter },
+	"data_listcontainsitem":  { "{LIST} contains {ITEM}?", shapeBoolean },
+	"data_showlist":          { "show list {LIST}", shapeStack },
+	"data_hidelist":          { "hide list {LIST}", shapeStack },
+
+	// pen
+	"pen_clear":                  { "erase all", shapeStack },
+	"pen_stamp":                  { "stamp", shapeStack },
+	"pen_penDown":                { "pen down", shapeStack },
+	"pen_penUp":                  { "pen up", shapeStack },
+	"pen_setPenColorToColor":     { "set pen color to {COLOR}", shapeStack },
+	"pen_changePenColorParamBy":  { "change pen {COLOR_PARAM} by {VALUE}", shapeStack },
+	"pen_setPenColorParamTo":     { "set pen {COLOR_PARAM} to {VALUE}", shapeStack },
+	"pen_changePenSizeBy":        { "change pen size by {SIZE}", shapeStack },
+	"pen_setPenSizeTo":           { "set pen size to {SIZE}", shapeStack },
+}
+
+// objectMenu maps the internal values of the special items in menus that list
+// sprites to the text that is shown for them.
+var objectMenu = map[string] string {
+	"_mouse_":  "mouse-pointer",
+	"_random_": "random position",
+	"_edge_":   "edge",
+	"_myself_": "myself",
+	"_stage_":  "Stage",
+}
+
+// menuValues maps the names of menu fields to the text that is shown for the
+// internal values of their items. Only fields whose items are all built in are
+// listed, besides the special items of sprite menus, so that names chosen by
+// the user are never changed.
+var menuValues = map[string] map[string] string {
+	"TO":                 objectMenu,
+	"TOWARDS":            objectMenu,
+	"TOUCHINGOBJECTMENU": objectMenu,
+	"DISTANCETOMENU":     objectMenu,
+	"CLONE_OPTION":       objectMenu,
+	"OBJECT":             objectMenu,
+
+	// graphic and sound effects
+	"EFFECT": {
+		"COLOR":      "color",
+		"FISHEYE":    "fisheye",
+		"WHIRL":      "whirl",
+		"PIXELATE":   "pixelate",
+		"MOSAIC":     "mosaic",
+		"BRIGHTNESS": "brightness",
+		"GHOST":      "ghost",
+		"PITCH":      "pitch",
+		"PAN":        "pan left/right",
+	},
+}
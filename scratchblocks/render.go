@@ -0,0 +1,363 @@
+/* Package scratchblocks renders the scripts of Scratch 3 projects as text in
+ * the scratchblocks syntax, which is used to show scripts on the Scratch forums
+ * and wiki.
+ */
+package scratchblocks
+
+import "fmt"
+import "regexp"
+import "strings"
+import "github.com/scapi3/sb3"
+
+// Indent is the text that is written before blocks once for every C block that
+// they are inside of.
+const Indent = "  "
+
+/* RenderProject renders every script in a project. The scripts of each target
+ * are preceded by a comment holding the name of the target, and scripts are
+ * separated by blank lines.
+ */
+func RenderProject (project *sb3.Project) (text string) {
+	builder := strings.Builder { }
+	for _, target := range project.Targets {
+		scripts := Render(target)
+		if len(scripts) == 0 { continue }
+		if builder.Len() > 0 { builder.WriteString("\n") }
+
+		builder.WriteString("// " + target.Name + "\n")
+		for _, script := range scripts {
+			builder.WriteString("\n" + script)
+		}
+	}
+	return builder.String()
+}
+
+/* Render renders every script of a target, in the order returned by
+ * Target.Scripts.
+ */
+func Render (target *sb3.Target) (scripts []string) {
+	for _, id := range target.Scripts() {
+		scripts = append(scripts, RenderScript(target, id))
+	}
+	return
+}
+
+/* RenderScript renders the script that starts with the block with the
+ * specified ID. Each block is written on its own line, and the text ends with a
+ * newline.
+ */
+func RenderScript (target *sb3.Target, id string) (script string) {
+	renderer := renderer {
+		target:    target,
+		rendering: map[string] bool { },
+	}
+	renderer.stack(id, 0)
+	return renderer.builder.String()
+}
+
+/* renderer holds the state of a script that is being rendered.
+ */
+type renderer struct {
+	target  *sb3.Target
+	builder strings.Builder
+
+	// rendering holds the IDs of the blocks that are currently being
+	// rendered, so that broken projects with blocks inside of themselves
+	// do not cause endless recursion.
+	rendering map[string] bool
+}
+
+/* line writes a line of text at the specified indentation level. If the block
+ * has a comment attached to it, the comment is written at the end of the line.
+ */
+func (renderer *renderer) line (indent int, text string, block *sb3.Block) {
+	renderer.builder.WriteString(strings.Repeat(Indent, indent))
+	renderer.builder.WriteString(text)
+	if block != nil && block.Comment != "" {
+		comment := renderer.target.Comments[block.Comment]
+		if comment != nil {
+			renderer.builder.WriteString(" // ")
+			renderer.builder.WriteString(strings.Join (
+				strings.Fields(comment.Text), " "))
+		}
+	}
+	renderer.builder.WriteString("\n")
+}
+
+/* stack renders a block and all of the blocks that come after it.
+ */
+func (renderer *renderer) stack (id string, indent int) {
+	for _, id := range renderer.target.Stack(id) {
+		if renderer.rendering[id] { return }
+		renderer.rendering[id] = true
+		renderer.statement(renderer.target.Blocks[id], indent)
+		delete(renderer.rendering, id)
+	}
+}
+
+/* statement renders a block that is part of a stack, along with the blocks
+ * inside of it if it is a C block.
+ */
+func (renderer *renderer) statement (block *sb3.Block, indent int) {
+	if block.Primitive != nil {
+		renderer.line(indent, renderer.primitive(block.Primitive), block)
+		return
+	}
+
+	switch block.Opcode {
+	case "procedures_definition":
+		renderer.line(indent, "define " + renderer.prototype(block), block)
+		return
+	case "procedures_call":
+		renderer.line(indent, renderer.call(block), block)
+		return
+	}
+
+	spec, ok := specs[block.Opcode]
+	if !ok {
+		renderer.line(indent, renderer.unknown(block), block)
+		return
+	}
+	if spec.shape == shapeReporter || spec.shape == shapeBoolean {
+		renderer.line(indent, renderer.reporter(block), block)
+		return
+	}
+
+	text := renderer.fill(block, spec.text)
+	switch spec.shape {
+	case shapeC:
+		renderer.line(indent, text, block)
+		renderer.substack(block, "SUBSTACK", indent + 1)
+		renderer.line(indent, "end", nil)
+	case shapeE:
+		renderer.line(indent, text, block)
+		renderer.substack(block, "SUBSTACK", indent + 1)
+		renderer.line(indent, "else", nil)
+		renderer.substack(block, "SUBSTACK2", indent + 1)
+		renderer.line(indent, "end", nil)
+	default:
+		renderer.line(indent, text, block)
+	}
+}
+
+/* substack renders the stack inside of a C block's input.
+ */
+func (renderer *renderer) substack (block *sb3.Block, name string, indent int) {
+	input, ok := block.Inputs[name]
+	if !ok || input.Block.BlockID == "" { return }
+	renderer.stack(input.Block.BlockID, indent)
+}
+
+// placeholder matches the names of inputs and fields in block spec text.
+var placeholder = regexp.MustCompile(`\{[A-Z0-9_]+\}`)
+
+/* fill replaces the input and field names in block spec text with the values
+ * of the block's inputs and fields.
+ */
+func (renderer *renderer) fill (block *sb3.Block, text string) (filled string) {
+	return placeholder.ReplaceAllStringFunc(text, func (match string) string {
+		return renderer.input(block, match[1:len(match) - 1])
+	})
+}
+
+/* input renders the value of a block's input or field. Empty inputs are
+ * rendered as empty boolean inputs, because every other kind of input has a
+ * shadow.
+ */
+func (renderer *renderer) input (block *sb3.Block, name string) (text string) {
+	input, ok := block.Inputs[name]
+	if !ok {
+		field, ok := block.Fields[name]
+		if !ok { return "<>" }
+		return "[" + escape(menuValue(name, field), "[]") + " v]"
+	}
+
+	value := input.Block
+	if value.IsEmpty() { value = input.Shadow }
+	if value.Primitive != nil { return renderer.primitive(value.Primitive) }
+	if value.BlockID == "" { return "<>" }
+
+	inner := renderer.target.Blocks[value.BlockID]
+	if inner == nil || renderer.rendering[value.BlockID] { return "()" }
+	if inner.Primitive != nil { return renderer.primitive(inner.Primitive) }
+
+	renderer.rendering[value.BlockID] = true
+	defer delete(renderer.rendering, value.BlockID)
+	return renderer.reporter(inner)
+}
+
+/* primitive renders a primitive.
+ */
+func (renderer *renderer) primitive (primitive *sb3.Primitive) (text string) {
+	value := ""
+	if primitive.Value != nil { value = fmt.Sprint(primitive.Value) }
+
+	switch primitive.Type {
+	case sb3.PrimitiveTypeColor, sb3.PrimitiveTypeText:
+		return "[" + escape(value, "[]") + "]"
+	case sb3.PrimitiveTypeBroadcast:
+		return "(" + escape(primitive.Name, "()") + " v)"
+	case sb3.PrimitiveTypeVariable:
+		return "(" + escape(primitive.Name, "()") + ")"
+	case sb3.PrimitiveTypeList:
+		return "(" + escape(primitive.Name, "()") + " :: list)"
+	default:
+		return "(" + escape(value, "()") + ")"
+	}
+}
+
+/* reporter renders a block that is inside of an input, such as a reporter, a
+ * boolean, a menu, or a shadow block holding a value.
+ */
+func (renderer *renderer) reporter (block *sb3.Block) (text string) {
+	switch block.Opcode {
+	case
+		"math_number", "math_positive_number", "math_whole_number",
+		"math_integer", "math_angle":
+		return "(" + escape(fieldValue(block, "NUM"), "()") + ")"
+	case "text":
+		return "[" + escape(fieldValue(block, "TEXT"), "[]") + "]"
+	case "colour_picker":
+		return "[" + escape(fieldValue(block, "COLOUR"), "[]") + "]"
+	case "data_variable":
+		return "(" + escape(fieldValue(block, "VARIABLE"), "()") + ")"
+	case "data_listcontents":
+		return "(" + escape(fieldValue(block, "LIST"), "()") + " :: list)"
+	case "argument_reporter_string_number":
+		return "(" + escape(fieldValue(block, "VALUE"), "()") + ")"
+	case "argument_reporter_boolean":
+		return "<" + escape(fieldValue(block, "VALUE"), "<>") + ">"
+	case "procedures_call":
+		return "(" + renderer.call(block) + ")"
+	}
+
+	spec, ok := specs[block.Opcode]
+	if ok {
+		text := renderer.fill(block, spec.text)
+		if spec.shape == shapeBoolean { return "<" + text + ">" }
+		if spec.shape == shapeReporter { return "(" + text + ")" }
+		return text
+	}
+
+	// shadow blocks with a single field and no inputs are menus
+	if block.Shadow && len(block.Inputs) == 0 && len(block.Fields) == 1 {
+		for name, field := range block.Fields {
+			return "(" + escape(menuValue(name, field), "()") + " v)"
+		}
+	}
+
+	return "(" + renderer.unknown(block) + ")"
+}
+
+/* unknown renders a block that has no block spec, such as a block from an
+ * extension. Its opcode is written followed by all of its inputs and fields,
+ * and it is given the grey color that scratchblocks uses for unknown blocks.
+ */
+func (renderer *renderer) unknown (block *sb3.Block) (text string) {
+	parts := []string { block.Opcode }
+	for _, name := range sb3.SortedKeys(block.Inputs) {
+		if strings.HasPrefix(name, "SUBSTACK") { continue }
+		parts = append(parts, renderer.input(block, name))
+	}
+	for _, name := range sb3.SortedKeys(block.Fields) {
+		parts = append(parts, renderer.input(block, name))
+	}
+	return strings.Join(parts, " ") + " :: grey"
+}
+
+/* prototype renders the prototype of a custom block definition, with the
+ * custom block's arguments in place of its inputs.
+ */
+func (renderer *renderer) prototype (definition *sb3.Block) (text string) {
+	input := definition.Inputs["custom_block"]
+	prototype := renderer.target.Blocks[input.Block.BlockID]
+	if prototype == nil || prototype.Mutation == nil { return "" }
+
+	names, _ := prototype.Mutation.ArgumentNameList()
+	return procedureText (
+		prototype.Mutation.ProcCode,
+		func (index int, kind byte) string {
+			name := ""
+			if index < len(names) { name = names[index] }
+			if kind == 'b' { return "<" + escape(name, "<>") + ">" }
+			return "(" + escape(name, "()") + ")"
+		})
+}
+
+/* call renders a custom block call, with the values of its inputs in place of
+ * its arguments.
+ */
+func (renderer *renderer) call (block *sb3.Block) (text string) {
+	if block.Mutation == nil { return renderer.unknown(block) }
+
+	ids, _ := block.Mutation.ArgumentIDList()
+	return procedureText (
+		block.Mutation.ProcCode,
+		func (index int, kind byte) string {
+			if index >= len(ids) { return "<>" }
+			return renderer.input(block, ids[index])
+		}) + " :: custom"
+}
+
+/* procedureText replaces the argument placeholders in a custom block's proccode
+ * with the text returned by argument. Argument is given the index of the
+ * argument, and its kind, which is 's' for text and numbers, 'n' for numbers in
+ * older projects, and 'b' for booleans.
+ */
+func procedureText (
+	procCode string,
+	argument func (index int, kind byte) string,
+) (
+	text string,
+) {
+	builder := strings.Builder { }
+	index   := 0
+	for position := 0; position < len(procCode); position ++ {
+		character := procCode[position]
+		if character == '%' && position + 1 < len(procCode) {
+			kind := procCode[position + 1]
+			if kind == 's' || kind == 'n' || kind == 'b' {
+				builder.WriteString(argument(index, kind))
+				index    ++
+				position ++
+				continue
+			}
+		}
+		builder.WriteByte(character)
+	}
+	return builder.String()
+}
+
+/* fieldValue returns the value of a block's field as a string, or a blank
+ * string if the block has no such field.
+ */
+func fieldValue (block *sb3.Block, name string) (value string) {
+	field, ok := block.Fields[name]
+	if !ok || field.Value == nil { return "" }
+	return field.String()
+}
+
+/* menuValue returns the text that is shown for the value of the menu field with
+ * the specified name. Values of fields that can hold names chosen by the user,
+ * such as variables and costumes, are returned as they are.
+ */
+func menuValue (name string, field sb3.Field) (value string) {
+	if field.Value == nil { return "" }
+	value = field.String()
+	if shown, ok := menuValues[name][value]; ok { return shown }
+	return
+}
+
+/* escape puts a backslash before backslashes and the specified characters, so
+ * that they are not mistaken for the brackets around an input.
+ */
+func escape (text string, characters string) (escaped string) {
+	builder := strings.Builder { }
+	for _, character := range text {
+		if character == '\\' || strings.ContainsRune(characters, character) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(character)
+	}
+	return builder.String()
+}
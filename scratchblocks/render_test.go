@@ -0,0 +1,34 @@
+package scratchblocks
+
+import "testing"
+import "encoding/json"
+import "github.com/scapi3/sb3"
+
+func TestMenuValues (test *testing.T) {
+	target := &sb3.Target { }
+	err := json.Unmarshal([]byte(`{
+		"isStage": false,
+		"name": "Sprite1",
+		"blocks": {
+			"a": {"opcode": "event_whenflagclicked", "next": "b", "parent": null, "inputs": {}, "fields": {}, "shadow": false, "topLevel": true, "x": 0, "y": 0},
+			"b": {"opcode": "data_setvariableto", "next": "c", "parent": "a", "inputs": {"VALUE": [1, [10, "0"]]}, "fields": {"VARIABLE": ["PAN", "v"]}, "shadow": false, "topLevel": false},
+			"c": {"opcode": "sound_seteffectto", "next": "d", "parent": "b", "inputs": {"VALUE": [1, [4, "100"]]}, "fields": {"EFFECT": ["PAN", null]}, "shadow": false, "topLevel": false},
+			"d": {"opcode": "looks_switchcostumeto", "next": "f", "parent": "c", "inputs": {"COSTUME": [1, "e"]}, "fields": {}, "shadow": false, "topLevel": false},
+			"e": {"opcode": "looks_costume", "next": null, "parent": "d", "inputs": {}, "fields": {"COSTUME": ["_mouse_", null]}, "shadow": true, "topLevel": false},
+			"f": {"opcode": "motion_goto", "next": null, "parent": "d", "inputs": {"TO": [1, "g"]}, "fields": {}, "shadow": false, "topLevel": false},
+			"g": {"opcode": "motion_goto_menu", "next": null, "parent": "f", "inputs": {}, "fields": {"TO": ["_mouse_", null]}, "shadow": true, "topLevel": false}
+		}
+	}`), target)
+	if err != nil { test.Fatal(err) }
+
+	expected :=
+		"when green flag clicked\n" +
+		"set [PAN v] to [0]\n" +
+		"set [pan left/right v] effect to (100)\n" +
+		"switch costume to (_mouse_ v)\n" +
+		"go to (mouse-pointer v)\n"
+	script := RenderScript(target, "a")
+	if script != expected {
+		test.Fatalf("rendered:\n%s\nexpected:\n%s", script, expected)
+	}
+}
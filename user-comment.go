@@ -0,0 +1,193 @@
+package scapi3
+
+import "fmt"
+import "strconv"
+
+/* DeleteProjectComment deletes a comment on a project. This can be done by the
+ * author of the comment, or by the owner of the project.
+ */
+func (session *UserSession) DeleteProjectComment (
+	project   uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"delete", commentID, MethodDelete,
+		"/proxy/comments/project/" + strconv.FormatUint(project, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10))
+}
+
+/* ReportProjectComment reports a comment on a project to the Scratch Team.
+ */
+func (session *UserSession) ReportProjectComment (
+	project   uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"report", commentID, MethodPost,
+		"/proxy/project/" + strconv.FormatUint(project, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10) + "/report")
+}
+
+/* RestoreProjectComment restores a deleted or reported comment on a project.
+ * This uses an admin endpoint, so it only works for Scratch Team accounts.
+ * For anyone else, the Scratch servers refuse the request.
+ */
+func (session *UserSession) RestoreProjectComment (
+	project   uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"restore", commentID, MethodPut,
+		"/proxy/admin/project/" + strconv.FormatUint(project, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10) + "/undelete")
+}
+
+/* DeleteStudioComment deletes a comment on a studio. This can be done by the
+ * author of the comment, or by a manager of the studio.
+ */
+func (session *UserSession) DeleteStudioComment (
+	studio    uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"delete", commentID, MethodDelete,
+		"/proxy/comments/studio/" + strconv.FormatUint(studio, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10))
+}
+
+/* ReportStudioComment reports a comment on a studio to the Scratch Team.
+ */
+func (session *UserSession) ReportStudioComment (
+	studio    uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"report", commentID, MethodPost,
+		"/proxy/studio/" + strconv.FormatUint(studio, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10) + "/report")
+}
+
+/* RestoreStudioComment restores a deleted or reported comment on a studio.
+ * This uses an admin endpoint, so it only works for Scratch Team accounts.
+ * For anyone else, the Scratch servers refuse the request.
+ */
+func (session *UserSession) RestoreStudioComment (
+	studio    uint64,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.commentAction (
+		"restore", commentID, MethodPut,
+		"/proxy/admin/studio/" + strconv.FormatUint(studio, 10) +
+		"/comment/" + strconv.FormatUint(commentID, 10) + "/undelete")
+}
+
+/* DeleteUserComment deletes a comment on a user's profile. This can be done by
+ * the author of the comment, or by the owner of the profile.
+ */
+func (session *UserSession) DeleteUserComment (
+	user      string,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.userCommentAction("delete", user, commentID, "del")
+}
+
+/* ReportUserComment reports a comment on a user's profile to the Scratch Team.
+ */
+func (session *UserSession) ReportUserComment (
+	user      string,
+	commentID uint64,
+) (
+	err error,
+) {
+	return session.userCommentAction("report", user, commentID, "rep")
+}
+
+/* ToggleProfileComments toggles whether comments can be posted on the session
+ * user's profile.
+ */
+func (session *UserSession) ToggleProfileComments () (err error) {
+	target := "profile of " + session.username
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot toggle comments on %s: session is not loaded",
+			target)
+	}
+
+	response, _, err := session.siteAPIRequest (
+		MethodPost,
+		"/comments/user/" + session.username + "/toggle-comments/",
+		nil)
+	if err != nil {
+		return fmt.Errorf (
+			"cannot toggle comments on %s: %v",
+			target, err)
+	}
+	return checkAction("toggle comments on", target, response)
+}
+
+/* commentAction sends an authenticated request concerning a comment to
+ * api.scratch.mit.edu.
+ */
+func (session *UserSession) commentAction (
+	action    string,
+	commentID uint64,
+	method    Method,
+	path      string,
+) (
+	err error,
+) {
+	target := "comment " + strconv.FormatUint(commentID, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot %s %s: session is not loaded",
+			action, target)
+	}
+
+	response, _, err := session.apiRequest(method, path, nil)
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %v", action, target, err)
+	}
+	return checkAction(action, target, response)
+}
+
+/* userCommentAction sends a site-api request concerning a comment on a user's
+ * profile.
+ */
+func (session *UserSession) userCommentAction (
+	action    string,
+	user      string,
+	commentID uint64,
+	what      string,
+) (
+	err error,
+) {
+	target := "comment " + strconv.FormatUint(commentID, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot %s %s: session is not loaded",
+			action, target)
+	}
+
+	response, _, err := session.siteAPIRequest (
+		MethodPost,
+		"/comments/user/" + user + "/" + what + "/",
+		CommentIDRequest { ID: commentID })
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %v", action, target, err)
+	}
+	return checkAction(action, target, response)
+}
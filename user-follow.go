@@ -0,0 +1,67 @@
+package scapi3
+
+import "fmt"
+import "strconv"
+import "net/url"
+
+/* FollowUser follows a user as the session's user. If the user does not exist,
+ * the returned error matches ErrNotFound.
+ */
+func (session *UserSession) FollowUser (name string) (err error) {
+	return session.followAction (
+		"follow", "user " + name,
+		"/users/followers/" + url.PathEscape(name) + "/add/")
+}
+
+/* UnfollowUser unfollows a user as the session's user. If the user does not
+ * exist, the returned error matches ErrNotFound.
+ */
+func (session *UserSession) UnfollowUser (name string) (err error) {
+	return session.followAction (
+		"unfollow", "user " + name,
+		"/users/followers/" + url.PathEscape(name) + "/remove/")
+}
+
+/* FollowStudio follows a studio as the session's user. If the studio does not
+ * exist, the returned error matches ErrNotFound.
+ */
+func (session *UserSession) FollowStudio (id uint64) (err error) {
+	return session.followAction (
+		"follow", "studio " + strconv.FormatUint(id, 10),
+		"/users/bookmarkers/" + strconv.FormatUint(id, 10) + "/add/")
+}
+
+/* UnfollowStudio unfollows a studio as the session's user. If the studio does
+ * not exist, the returned error matches ErrNotFound.
+ */
+func (session *UserSession) UnfollowStudio (id uint64) (err error) {
+	return session.followAction (
+		"unfollow", "studio " + strconv.FormatUint(id, 10),
+		"/users/bookmarkers/" + strconv.FormatUint(id, 10) + "/remove/")
+}
+
+/* followAction adds or removes the session's user from a site-api list of
+ * followers.
+ */
+func (session *UserSession) followAction (
+	action string,
+	target string,
+	path   string,
+) (
+	err error,
+) {
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot %s %s: session is not loaded",
+			action, target)
+	}
+
+	response, _, err := session.siteAPIRequest (
+		MethodPut,
+		path + "?usernames=" + url.QueryEscape(session.username),
+		nil)
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %v", action, target, err)
+	}
+	return checkAction(action, target, response)
+}
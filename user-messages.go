@@ -0,0 +1,287 @@
+package scapi3
+
+import "fmt"
+import "encoding/json"
+
+/* MessageType represents the type of a message in a user's inbox.
+ */
+type MessageType string
+
+const (
+	MessageTypeFollowUser       MessageType = "followuser"
+	MessageTypeLoveProject      MessageType = "loveproject"
+	MessageTypeFavoriteProject  MessageType = "favoriteproject"
+	MessageTypeAddComment       MessageType = "addcomment"
+	MessageTypeCuratorInvite    MessageType = "curatorinvite"
+	MessageTypeRemixProject     MessageType = "remixproject"
+	MessageTypeStudioActivity   MessageType = "studioactivity"
+	MessageTypeForumPost        MessageType = "forumpost"
+	MessageTypeBecomeHostStudio MessageType = "becomehoststudio"
+	MessageTypeUserJoin         MessageType = "userjoin"
+)
+
+/* Message is implemented by all message types. A type switch can be used to get
+ * the information specific to each type of message.
+ */
+type Message interface {
+	// Base returns the information that all messages have.
+	Base () (base MessageBase)
+}
+
+/* MessageBase holds the information that all messages have.
+ */
+type MessageBase struct {
+	ID              uint64      `json:"id"`
+	Type            MessageType `json:"type"`
+	DateTimeCreated string      `json:"datetime_created"`
+	ActorID         uint64      `json:"actor_id"`
+	ActorUsername   string      `json:"actor_username"`
+}
+
+/* Base returns the information that all messages have.
+ */
+func (structure MessageBase) Base () (base MessageBase) {
+	return structure
+}
+
+/* MessageFollowUser is sent when someone follows the user.
+ */
+type MessageFollowUser struct {
+	MessageBase
+	FollowedUserID   uint64 `json:"followed_user_id"`
+	FollowedUsername string `json:"followed_username"`
+}
+
+/* MessageLoveProject is sent when someone loves one of the user's projects.
+ */
+type MessageLoveProject struct {
+	MessageBase
+	ProjectID uint64 `json:"project_id"`
+	Title     string `json:"title"`
+}
+
+/* MessageFavoriteProject is sent when someone favorites one of the user's
+ * projects.
+ */
+type MessageFavoriteProject struct {
+	MessageBase
+	ProjectID    uint64 `json:"project_id"`
+	ProjectTitle string `json:"project_title"`
+}
+
+/* CommentType represents what a comment was posted on.
+ */
+type CommentType int
+
+const (
+	CommentTypeProject CommentType = 0
+	CommentTypeUser    CommentType = 1
+	CommentTypeStudio  CommentType = 2
+)
+
+/* MessageAddComment is sent when someone comments on something the user owns,
+ * or replies to one of the user's comments.
+ */
+type MessageAddComment struct {
+	MessageBase
+	CommentType        CommentType `json:"comment_type"`
+	CommentObjectID    uint64      `json:"comment_obj_id"`
+	CommentObjectTitle string      `json:"comment_obj_title"`
+	CommentID          uint64      `json:"comment_id"`
+	CommentFragment    string      `json:"comment_fragment"`
+	CommenteeUsername  string      `json:"commentee_username"`
+}
+
+/* MessageCuratorInvite is sent when the user is invited to curate a studio.
+ */
+type MessageCuratorInvite struct {
+	MessageBase
+	GalleryID uint64 `json:"gallery_id"`
+	Title     string `json:"title"`
+}
+
+/* MessageRemixProject is sent when someone remixes one of the user's projects.
+ */
+type MessageRemixProject struct {
+	MessageBase
+	ProjectID   uint64 `json:"project_id"`
+	Title       string `json:"title"`
+	ParentID    uint64 `json:"parent_id"`
+	ParentTitle string `json:"parent_title"`
+}
+
+/* MessageStudioActivity is sent when there is new activity in a studio the
+ * user curates.
+ */
+type MessageStudioActivity struct {
+	MessageBase
+	GalleryID uint64 `json:"gallery_id"`
+	Title     string `json:"title"`
+}
+
+/* MessageForumPost is sent when someone posts in a forum topic the user
+ * follows.
+ */
+type MessageForumPost struct {
+	MessageBase
+	TopicID    uint64 `json:"topic_id"`
+	TopicTitle string `json:"topic_title"`
+}
+
+/* MessageBecomeHostStudio is sent when the user is made the host of a studio.
+ */
+type MessageBecomeHostStudio struct {
+	MessageBase
+	GalleryID          uint64 `json:"gallery_id"`
+	GalleryTitle       string `json:"gallery_title"`
+	FormerHostUsername string `json:"former_host_username"`
+	RecipientUsername  string `json:"recipient_username"`
+	AdminActor         bool   `json:"admin_actor"`
+}
+
+/* MessageUserJoin is sent when the user joins Scratch.
+ */
+type MessageUserJoin struct {
+	MessageBase
+}
+
+/* MessageUnknown is used for messages of a type that this package does not
+ * know about. It holds the original JSON data of the message.
+ */
+type MessageUnknown struct {
+	MessageBase
+	Data json.RawMessage
+}
+
+/* MessagesResponse represents a page of messages from a user's inbox.
+ */
+type MessagesResponse []Message
+
+/* UnmarshalJSON decodes a list of messages, choosing a concrete type for each
+ * one depending on its type field.
+ */
+func (structure *MessagesResponse) UnmarshalJSON (data []byte) (err error) {
+	rawMessages := []json.RawMessage { }
+	err = json.Unmarshal(data, &rawMessages)
+	if err != nil { return }
+
+	*structure = make(MessagesResponse, 0, len(rawMessages))
+	for _, raw := range rawMessages {
+		var message Message
+		message, err = UnmarshalMessage(raw)
+		if err != nil { return }
+		*structure = append(*structure, message)
+	}
+	return
+}
+
+/* UnmarshalMessage takes in a JSON encoded message and returns it as the
+ * concrete type that matches its type field. Messages of unknown types are
+ * returned as MessageUnknown.
+ */
+func UnmarshalMessage (data []byte) (message Message, err error) {
+	base := MessageBase { }
+	err = json.Unmarshal(data, &base)
+	if err != nil { return }
+
+	switch base.Type {
+	case MessageTypeFollowUser:       message = &MessageFollowUser       { }
+	case MessageTypeLoveProject:      message = &MessageLoveProject      { }
+	case MessageTypeFavoriteProject:  message = &MessageFavoriteProject  { }
+	case MessageTypeAddComment:       message = &MessageAddComment       { }
+	case MessageTypeCuratorInvite:    message = &MessageCuratorInvite    { }
+	case MessageTypeRemixProject:     message = &MessageRemixProject     { }
+	case MessageTypeStudioActivity:   message = &MessageStudioActivity   { }
+	case MessageTypeForumPost:        message = &MessageForumPost        { }
+	case MessageTypeBecomeHostStudio: message = &MessageBecomeHostStudio { }
+	case MessageTypeUserJoin:         message = &MessageUserJoin         { }
+	default:
+		return &MessageUnknown {
+			MessageBase: base,
+			Data:        append(json.RawMessage(nil), data...),
+		}, nil
+	}
+
+	err = json.Unmarshal(data, message)
+	if err != nil {
+		return nil, fmt.Errorf (
+			"cannot parse %s message %d: %v",
+			base.Type, base.ID, err)
+	}
+	return
+}
+
+/* GetMessages returns a page of messages from the session user's inbox, newest
+ * first.
+ */
+func (session *UserSession) GetMessages (
+	limit  int,
+	offset int,
+) (
+	structure MessagesResponse,
+	err error,
+) {
+	if !session.loaded {
+		err = fmt.Errorf("cannot get messages: session is not loaded")
+		return
+	}
+	
+	err = SessionRestRequest (
+		session, &structure, "/users/" + session.username + "/messages",
+		limit, offset)
+	return
+}
+
+/* MessagesPageSize is the amount of messages requested at once by
+ * GetAllMessages and EachMessage. It is the largest limit that Scratch allows.
+ */
+const MessagesPageSize = 40
+
+/* EachMessage walks through every message in the session user's inbox, newest
+ * first, calling callback for each one. If callback returns false, the walk is
+ * stopped.
+ */
+func (session *UserSession) EachMessage (
+	callback func (message Message) bool,
+) (
+	err error,
+) {
+	for offset := 0; ; offset += MessagesPageSize {
+		var page MessagesResponse
+		page, err = session.GetMessages(MessagesPageSize, offset)
+		if err != nil { return }
+
+		for _, message := range page {
+			if !callback(message) { return }
+		}
+		if len(page) < MessagesPageSize { return }
+	}
+}
+
+/* GetAllMessages returns every message in the session user's inbox, newest
+ * first.
+ */
+func (session *UserSession) GetAllMessages () (messages []Message, err error) {
+	err = session.EachMessage (func (message Message) bool {
+		messages = append(messages, message)
+		return true
+	})
+	return
+}
+
+/* ClearMessages marks all messages in the session user's inbox as read, which
+ * resets their message count to zero.
+ */
+func (session *UserSession) ClearMessages () (err error) {
+	if !session.loaded {
+		return fmt.Errorf("cannot clear messages: session is not loaded")
+	}
+
+	response, _, err := session.siteAPIRequest (
+		MethodPost, "/messages/messages-clear/",
+		nil)
+	if err != nil {
+		return fmt.Errorf("cannot clear messages: %v", err)
+	}
+	return checkAction("clear", "messages", response)
+}
@@ -0,0 +1,128 @@
+package scapi3
+
+import "fmt"
+import "net/url"
+
+/* FeaturedProjectLabel represents the heading shown above the featured project
+ * on a user's profile.
+ */
+type FeaturedProjectLabel string
+
+const (
+	FeaturedProjectLabelFeaturedProject  FeaturedProjectLabel = ""
+	FeaturedProjectLabelFeaturedTutorial FeaturedProjectLabel = "0"
+	FeaturedProjectLabelWorkInProgress   FeaturedProjectLabel = "1"
+	FeaturedProjectLabelRemixThis        FeaturedProjectLabel = "2"
+	FeaturedProjectLabelFavoriteThings   FeaturedProjectLabel = "3"
+	FeaturedProjectLabelWhyIScratch      FeaturedProjectLabel = "4"
+)
+
+/* SetProfileBio changes the "About me" section of the session user's profile.
+ */
+func (session *UserSession) SetProfileBio (bio string) (err error) {
+	return session.UpdateProfile(ProfileUpdateRequest { Bio: &bio })
+}
+
+/* SetProfileStatus changes the "What I'm working on" section of the session
+ * user's profile.
+ */
+func (session *UserSession) SetProfileStatus (status string) (err error) {
+	return session.UpdateProfile(ProfileUpdateRequest { Status: &status })
+}
+
+/* SetFeaturedProject changes the featured project on the session user's
+ * profile, along with the label shown above it.
+ */
+func (session *UserSession) SetFeaturedProject (
+	project uint64,
+	label   FeaturedProjectLabel,
+) (
+	err error,
+) {
+	labelString := string(label)
+	return session.UpdateProfile(ProfileUpdateRequest {
+		FeaturedProject:      &project,
+		FeaturedProjectLabel: &labelString,
+	})
+}
+
+/* UpdateProfile changes the session user's profile.
+ */
+func (session *UserSession) UpdateProfile (
+	update ProfileUpdateRequest,
+) (
+	err error,
+) {
+	target := "profile of " + session.username
+	if !session.loaded {
+		return fmt.Errorf("cannot update %s: session is not loaded", target)
+	}
+
+	response, _, err := session.siteAPIRequest (
+		MethodPut, "/users/all/" + session.username + "/",
+		update)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %v", target, err)
+	}
+	return checkAction("update", target, response)
+}
+
+/* SetProfileCountry changes the country shown on the session user's profile.
+ * The country must be given as its English name, as listed on the Scratch
+ * account settings page.
+ */
+func (session *UserSession) SetProfileCountry (country string) (err error) {
+	target := "country of " + session.username
+	if !session.loaded {
+		return fmt.Errorf("cannot update %s: session is not loaded", target)
+	}
+
+	form := url.Values { }
+	form.Set("csrfmiddlewaretoken", session.CSRFToken())
+	form.Set("country", country)
+
+	response, _, err := session.send(Request {
+		Path:   "/accounts/settings/",
+		Method: MethodPost,
+		Body:   RawRequestBody(form.Encode()),
+
+		Headers: map[string] string {
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Origin":       "https://scratch.mit.edu",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %v", target, err)
+	}
+	return checkAction("update", target, response)
+}
+
+/* SetAvatar uploads a new profile picture for the session's user. The image
+ * must be in a format that the Scratch website accepts, such as PNG, JPEG, or
+ * GIF.
+ */
+func (session *UserSession) SetAvatar (image []byte) (err error) {
+	target := "avatar of " + session.username
+	if !session.loaded {
+		return fmt.Errorf("cannot update %s: session is not loaded", target)
+	}
+	
+	body, contentType, err := multipartFile("file", "avatar", image)
+	if err != nil { return }
+
+	response, _, err := session.send(Request {
+		Path:   "/site-api/users/all/" + session.username + "/",
+		Method: MethodPost,
+		Body:   body,
+
+		Headers: map[string] string {
+			"X-Requested-With": "XMLHttpRequest",
+			"Content-Type":     contentType,
+			"Origin":           "https://scratch.mit.edu",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %v", target, err)
+	}
+	return checkAction("update", target, response)
+}
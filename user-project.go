@@ -0,0 +1,284 @@
+package scapi3
+
+import "io"
+import "fmt"
+import "bytes"
+import "strconv"
+import "image/png"
+import "encoding/json"
+
+/* LoveProject loves a project as the session's user.
+ */
+func (session *UserSession) LoveProject (id uint64) (err error) {
+	return session.projectAction("love", MethodPost, id, "loves")
+}
+
+/* UnloveProject removes the session user's love from a project.
+ */
+func (session *UserSession) UnloveProject (id uint64) (err error) {
+	return session.projectAction("unlove", MethodDelete, id, "loves")
+}
+
+/* FavoriteProject favorites a project as the session's user.
+ */
+func (session *UserSession) FavoriteProject (id uint64) (err error) {
+	return session.projectAction("favorite", MethodPost, id, "favorites")
+}
+
+/* UnfavoriteProject removes a project from the session user's favorites.
+ */
+func (session *UserSession) UnfavoriteProject (id uint64) (err error) {
+	return session.projectAction (
+		"unfavorite", MethodDelete, id, "favorites")
+}
+
+/* IsProjectLoved returns whether the session's user has loved a project.
+ */
+func (session *UserSession) IsProjectLoved (id uint64) (loved bool, err error) {
+	structure := LoveStatusResponse { }
+	err = SessionRestRequest (
+		session, &structure,
+		"/projects/" + strconv.FormatUint(id, 10) +
+		"/loves/user/" + session.username,
+		0, 0)
+	loved = structure.UserLove
+	return
+}
+
+/* IsProjectFavorited returns whether the session's user has favorited a
+ * project.
+ */
+func (session *UserSession) IsProjectFavorited (
+	id uint64,
+) (
+	favorited bool,
+	err       error,
+) {
+	structure := FavoriteStatusResponse { }
+	err = SessionRestRequest (
+		session, &structure,
+		"/projects/" + strconv.FormatUint(id, 10) +
+		"/favorites/user/" + session.username,
+		0, 0)
+	favorited = structure.UserFavorite
+	return
+}
+
+/* SetProjectTitle changes the title of a project, and returns the updated
+ * project.
+ */
+func (session *UserSession) SetProjectTitle (
+	id    uint64,
+	title string,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	return session.UpdateProjectInfo(id, ProjectUpdateRequest {
+		Title: &title,
+	})
+}
+
+/* SetProjectInstructions changes the instructions of a project, and returns the
+ * updated project.
+ */
+func (session *UserSession) SetProjectInstructions (
+	id           uint64,
+	instructions string,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	return session.UpdateProjectInfo(id, ProjectUpdateRequest {
+		Instructions: &instructions,
+	})
+}
+
+/* SetProjectNotes changes the notes and credits of a project, and returns the
+ * updated project.
+ */
+func (session *UserSession) SetProjectNotes (
+	id    uint64,
+	notes string,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	return session.UpdateProjectInfo(id, ProjectUpdateRequest {
+		Description: &notes,
+	})
+}
+
+/* SetProjectCommentsAllowed sets whether comments can be posted on a project,
+ * and returns the updated project.
+ */
+func (session *UserSession) SetProjectCommentsAllowed (
+	id      uint64,
+	allowed bool,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	return session.UpdateProjectInfo(id, ProjectUpdateRequest {
+		CommentsAllowed: &allowed,
+	})
+}
+
+/* UpdateProjectInfo changes the metadata of a project, and returns the updated
+ * project.
+ */
+func (session *UserSession) UpdateProjectInfo (
+	id     uint64,
+	update ProjectUpdateRequest,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	target := "project " + strconv.FormatUint(id, 10)
+	if !session.loaded {
+		err = fmt.Errorf("cannot update %s: session is not loaded", target)
+		return
+	}
+	
+	response, body, err := session.apiRequest (
+		MethodPut, "/projects/" + strconv.FormatUint(id, 10),
+		update)
+	if err != nil {
+		err = fmt.Errorf("cannot update %s: %v", target, err)
+		return
+	}
+	err = checkAction("update", target, response)
+	if err != nil { return }
+
+	err = json.Unmarshal(body, &structure)
+	if err != nil {
+		err = fmt.Errorf (
+			"cannot parse server response (%s): %v",
+			response.Status, err)
+	}
+	return
+}
+
+/* ShareProject shares a project, and returns the updated project.
+ */
+func (session *UserSession) ShareProject (
+	id uint64,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	err = session.projectAction("share", MethodPut, id, "share")
+	if err != nil { return }
+	return session.GetProject(id)
+}
+
+/* UnshareProject unshares a project, and returns the updated project.
+ */
+func (session *UserSession) UnshareProject (
+	id uint64,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	err = session.projectAction("unshare", MethodPut, id, "unshare")
+	if err != nil { return }
+	return session.GetProject(id)
+}
+
+// MaxThumbnailWidth and MaxThumbnailHeight are the largest dimensions accepted
+// by SetProjectThumbnail. They match the size of the Scratch stage.
+const (
+	MaxThumbnailWidth  = 480
+	MaxThumbnailHeight = 360
+)
+
+/* SetProjectThumbnail uploads a new thumbnail for a project. The image must be
+ * a PNG no larger than MaxThumbnailWidth by MaxThumbnailHeight pixels, and is
+ * checked before it is sent.
+ */
+func (session *UserSession) SetProjectThumbnail (
+	id        uint64,
+	thumbnail io.Reader,
+) (
+	err error,
+) {
+	target := "project " + strconv.FormatUint(id, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot set thumbnail of %s: session is not loaded",
+			target)
+	}
+
+	data, err := io.ReadAll(thumbnail)
+	if err != nil { return }
+	err = validateThumbnail(data)
+	if err != nil {
+		return fmt.Errorf("cannot set thumbnail of %s: %v", target, err)
+	}
+
+	response, _, err := session.send(Request {
+		Path:   "/internalapi/project/thumbnail/" +
+			strconv.FormatUint(id, 10) + "/set/",
+		Method: MethodPost,
+		Body:   RawRequestBody(data),
+
+		Headers: map[string] string {
+			"Content-Type": "image/png",
+			"Origin":       "https://scratch.mit.edu",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set thumbnail of %s: %v", target, err)
+	}
+	return checkAction("set thumbnail of", target, response)
+}
+
+/* validateThumbnail checks that image data is a PNG of an acceptable size.
+ */
+func validateThumbnail (data []byte) (err error) {
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("image is not a valid PNG: %v", err)
+	}
+
+	if config.Width < 1 || config.Height < 1 {
+		return fmt.Errorf("image is empty")
+	}
+	if config.Width > MaxThumbnailWidth || config.Height > MaxThumbnailHeight {
+		return fmt.Errorf (
+			"image is %dx%d, which is larger than %dx%d",
+			config.Width, config.Height,
+			MaxThumbnailWidth, MaxThumbnailHeight)
+	}
+	return
+}
+
+/* projectAction sends a request concerning a project to the
+ * api.scratch.mit.edu proxy. Loves and favorites are sent on behalf of the
+ * session's user.
+ */
+func (session *UserSession) projectAction (
+	action string,
+	method Method,
+	id     uint64,
+	what   string,
+) (
+	err error,
+) {
+	if !session.loaded {
+		return fmt.Errorf("cannot %s project: session is not loaded", action)
+	}
+
+	path := "/proxy/projects/" + strconv.FormatUint(id, 10) + "/" + what
+	if what == "loves" || what == "favorites" {
+		path += "/user/" + session.username
+	}
+	
+	response, _, err := session.apiRequest(method, path, nil)
+	if err != nil {
+		return fmt.Errorf("cannot %s project: %v", action, err)
+	}
+	return checkAction (
+		action, "project " + strconv.FormatUint(id, 10),
+		response)
+}
@@ -0,0 +1,561 @@
+package scapi3
+
+import "strconv"
+
+// These methods mirror the functions in rest.go, but send the session's
+// credentials along with each request. They may be called on a nil session, in
+// which case the requests are anonymous.
+
+/* GetHealth returns information relating to the health of the scratch website.
+ */
+func (session *UserSession) GetHealth () (
+	structure HealthResponse,
+	err error,
+) {
+	err = SessionRestRequest(session, &structure, "/health", 0, 0)
+	return
+}
+
+/* GetNews returns recent news articles from the scratch website.
+ */
+func (session *UserSession) GetNews (
+	limit  int,
+	offset int,
+) (
+	structure NewsResponse,
+	err error,
+) {
+	err = SessionRestRequest(session, &structure, "/news", limit, offset)
+	return
+}
+
+/* GetProjectsCountAll returns the amount of projects that have been uploaded to
+ * the site.
+ */
+func (session *UserSession) GetProjectsCountAll () (count uint64, err error) {
+	structure := CountResponse { }
+	err = SessionRestRequest (
+		session, &structure, "/projects/count/all",
+		0, 0)
+	count = structure.Count
+	return
+}
+
+/* GetProject returns information about a project.
+ */
+func (session *UserSession) GetProject (
+	id uint64,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/projects/" + strconv.FormatUint(id, 10),
+		0, 0)
+	return
+}
+
+/* GetProjectRemixes returns the remixes of a project.
+ */
+func (session *UserSession) GetProjectRemixes (
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []ProjectResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/projects/" + strconv.FormatUint(id, 10) + "/remixes",
+		limit, offset)
+	return
+}
+
+/* GetStudio returns information about a studio.
+ */
+func (session *UserSession) GetStudio (
+	id uint64,
+) (
+	structure StudioResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/studios/" + strconv.FormatUint(id, 10),
+		0, 0)
+	return
+}
+
+/* GetStudioProjects returns a list of all projects in a studio.
+ */
+func (session *UserSession) GetStudioProjects (
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure StudioProjectsResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) + "/projects",
+		limit, offset)
+	return
+}
+
+/* GetStudioManagers returns a list of all managers of a studio.
+ */
+func (session *UserSession) GetStudioManagers (
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []UserResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) + "/managers",
+		limit, offset)
+	return
+}
+
+/* GetStudioCurators returns a list of all curators of a studio.
+ */
+func (session *UserSession) GetStudioCurators (
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []UserResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) + "/curators",
+		limit, offset)
+	return
+}
+
+/* GetStudioUserRole returns whether a user manages, curates, has been invited
+ * to, or follows a studio. The session must be logged in.
+ */
+func (session *UserSession) GetStudioUserRole (
+	id   uint64,
+	name string,
+) (
+	structure StudioRoleResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) + "/users/" + name,
+		0, 0)
+	return
+}
+
+/* GetStudioActivity returns a list of all recent activityin s studio. If since
+ * is blank, a date limit is not sent.
+ */
+func (session *UserSession) GetStudioActivity (
+	id uint64,
+	// TODO: make this a golang time
+	since string,
+	limit int,
+) (
+	structure StudioActivityResponse,
+	err error,
+) {
+	url := "/studios/" + strconv.FormatUint(id, 10) + "/activity"
+
+	if since != "" {
+		since = "dateLimit=" + since
+	}
+	
+	err = SessionRestRequestWithQueryString (
+		session, &structure, url,
+		limit, 0, since)
+	return
+}
+
+/* GetStudioComments returns a list of all comments on a studio.
+ */
+func (session *UserSession) GetStudioComments (
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) + "/comments",
+		limit, offset)
+	return
+}
+
+/* GetStudioComment returns a comment on a studio.
+ */
+func (session *UserSession) GetStudioComment (
+	id        uint64,
+	commentID uint64,
+) (
+	structure CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) +
+		"/comments/" + strconv.FormatUint(commentID, 10),
+		0, 0)
+	return
+}
+
+/* GetStudioCommentReplies returns the replies for a comment on a studio.
+ */
+func (session *UserSession) GetStudioCommentReplies (
+	id        uint64,
+	commentID uint64,
+	limit     int,
+	offset    int,
+) (
+	structure []CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure,
+		"/studios/" + strconv.FormatUint(id, 10) +
+		"/comments/" + strconv.FormatUint(commentID, 10) + "/replies",
+		limit, offset)
+	return
+}
+
+/* GetFeatured returns information about front paged projects.
+ */
+func (session *UserSession) GetFeatured () (
+	structure FeaturedResponse,
+	err error,
+) {
+	err = SessionRestRequest(session, &structure, "/proxy/featured", 0, 0)
+	return
+}
+
+/* GetUser returns information about a user.
+ */
+func (session *UserSession) GetUser (
+	name string,
+) (
+	structure UserResponse,
+	err error,
+) {
+	err = SessionRestRequest(session, &structure, "/users/" + name, 0, 0)
+	return
+}
+
+/* GetUserFavorites returns all projects favorited by a user.
+ */
+func (session *UserSession) GetUserFavorites (
+	name   string,
+	limit  int,
+	offset int,
+) (
+	structure []ProjectResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/favorites",
+		limit, offset)
+	return
+}
+
+/* GetUserFollowers returns all followers of a user.
+ */
+func (session *UserSession) GetUserFollowers (
+	name   string,
+	limit  int,
+	offset int,
+) (
+	structure []UserResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/followers",
+		limit, offset)
+	return
+}
+
+/* GetUserFollowing returns all users a user is following.
+ */
+func (session *UserSession) GetUserFollowing (
+	name   string,
+	limit  int,
+	offset int,
+) (
+	structure []UserResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/following",
+		limit, offset)
+	return
+}
+
+/* GetUserMessageCount returns the amount of messages a user has.
+ */
+func (session *UserSession) GetUserMessageCount (
+	name string,
+) (
+	count uint64,
+	err   error,
+) {
+	structure := CountResponse { }
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/messages/count",
+		0, 0)
+	count = structure.Count
+	return
+}
+
+/* GetUserProjects returns all projects made by a user.
+ */
+func (session *UserSession) GetUserProjects (
+	name   string,
+	limit  int,
+	offset int,
+) (
+	structure []ProjectResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects",
+		limit, offset)
+	return
+}
+
+/* GetUserProject returns a specific project made by a user.
+ */
+func (session *UserSession) GetUserProject (
+	name string,
+	id   uint64,
+) (
+	structure ProjectResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects/" +
+		strconv.FormatUint(id, 10),
+		0, 0)
+	return
+}
+
+/* GetUserProject returns a specific project made by a user.
+ */
+func (session *UserSession) GetUserProjectStudios (
+	name   string,
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []StudioResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects/" +
+		strconv.FormatUint(id, 10) + "/studios",
+		limit, offset)
+	return
+}
+
+/* GetUserProjectComments returns a list of all comments on a user's project.
+ */
+func (session *UserSession) GetUserProjectComments (
+	name   string,
+	id     uint64,
+	limit  int,
+	offset int,
+) (
+	structure []CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects/" +
+		strconv.FormatUint(id, 10) + "/comments",
+		limit, offset)
+	return
+}
+
+/* GetUserProjectComment returns information about a specific comment on a
+ * user's project.
+ */
+func (session *UserSession) GetUserProjectComment (
+	name      string,
+	id        uint64,
+	commentid uint64,
+	limit     int,
+	offset    int,
+) (
+	structure CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects/" +
+		strconv.FormatUint(id, 10) + "/comments/" +
+		strconv.FormatUint(commentid, 10),
+		limit, offset)
+	return
+}
+
+/* GetUserProjectCommentReplies returns replies to a specific comment on a
+ * user's project.
+ */
+func (session *UserSession) GetUserProjectCommentReplies (
+	name      string,
+	id        uint64,
+	commentid uint64,
+	limit     int,
+	offset    int,
+) (
+	structure []CommentResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/projects/" +
+		strconv.FormatUint(id, 10) + "/comments/" +
+		strconv.FormatUint(commentid, 10) + "/replies",
+		limit, offset)
+	return
+}
+
+/* GetUserStudiosCurate returns the studios that the user is a curator of.
+ */
+func (session *UserSession) GetUserStudiosCurate (
+	name   string,
+	limit  int,
+	offset int,
+) (
+	structure []StudioResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/users/" + name + "/studios/curate",
+		limit, offset)
+	return
+}
+
+/* GetAccountsCheckUsername checks whether a new account with the specified
+ * username can be created (that is, it isn't taken).
+ */
+func (session *UserSession) GetAccountsCheckUsername (
+	name string,
+) (
+	structure AccountsCheckUsernameResponse,
+	err error,
+) {
+	err = SessionRestRequest (
+		session, &structure, "/accounts/checkusername/" + name,
+		0, 0)
+	return
+}
+
+func getSearchResults [T any] (
+	session   *UserSession,
+	where     string,
+	query     string,
+	mode      string,
+	language  string,
+	limit     int,
+	offset    int,
+	structure *T,
+) (
+	err error,
+) {
+	queryString := ""
+	if query     != "" { queryString += "q="        + query    + "&" }
+	if mode      != "" { queryString += "mode="     + mode     + "&" }
+	if language  != "" { queryString += "language=" + language + "&" }
+	
+	return SessionRestRequestWithQueryString (
+		session, structure, where,
+		limit, offset, queryString)
+}
+
+/* GetExploreProjects returns explore results for the projects tab.
+ */
+func (session *UserSession) GetExploreProjects (
+	query    string,
+	mode     string,
+	language string,
+	limit    int,
+	offset   int,
+) (
+	structure []ProjectResponse,
+	err error,
+) {
+	err = getSearchResults (
+		session,
+		"/explore/projects",
+		query, mode, language,
+		limit, offset, &structure)
+	return
+}
+
+/* GetExploreStudios returns explore results for the studios tab.
+ */
+func (session *UserSession) GetExploreStudios (
+	query    string,
+	mode     string,
+	language string,
+	limit    int,
+	offset   int,
+) (
+	structure []StudioResponse,
+	err error,
+) {
+	err = getSearchResults (
+		session,
+		"/explore/studios",
+		query, mode, language,
+		limit, offset, &structure)
+	return
+}
+
+/* GetSearchProjects returns search results for the projects tab.
+ */
+func (session *UserSession) GetSearchProjects (
+	query    string,
+	mode     string,
+	language string,
+	limit    int,
+	offset   int,
+) (
+	structure []ProjectResponse,
+	err error,
+) {
+	err = getSearchResults (
+		session,
+		"/search/projects",
+		query, mode, language,
+		limit, offset, &structure)
+	return
+}
+
+/* GetSearchStudios returns search results for the studios tab.
+ */
+func (session *UserSession) GetSearchStudios (
+	query    string,
+	mode     string,
+	language string,
+	limit    int,
+	offset   int,
+) (
+	structure []StudioResponse,
+	err error,
+) {
+	err = getSearchResults (
+		session,
+		"/search/studios",
+		query, mode, language,
+		limit, offset, &structure)
+	return
+}
@@ -1,9 +1,15 @@
 package scapi3
 
 import "fmt"
+import "os"
 import "strconv"
+import "net/url"
 import "net/http"
 import "encoding/json"
+import "net/http/cookiejar"
+
+// scratchURL is the URL that session cookies are stored under.
+var scratchURL = &url.URL { Scheme: "https", Host: "scratch.mit.edu", Path: "/" }
 
 /* UserSession represents a login session. It is used to access user-specific
  * parts of the API like cloud variables.
@@ -15,7 +21,16 @@ type UserSession struct {
 	password	string
 	id		int
 	token		string
-	sessionID	string
+	jar		*cookiejar.Jar
+	info		SessionInfo
+
+	// recovering is set while the session is checking whether it has
+	// expired, so that requests made in the process are not retried.
+	recovering	bool
+
+	onLogin		func ()
+	onLogout	func ()
+	onExpire	func ()
 }
 
 /* CreateUserSession creates and loads a new user session with the specified
@@ -37,12 +52,27 @@ func CreateUserSession (
 	return
 }
 
-/* Login connects to the scratch servers and starts the session.
+/* Login connects to the scratch servers and starts the session. If the session
+ * is already loaded, this function does nothing.
  */
 func (session *UserSession) Login () (err error) {
 	if session.loaded { return }
 
-	response, body, err := Request {
+	session.jar, _ = cookiejar.New(nil)
+	session.setCookie("scratchlanguage", "en")
+
+	// fetch a CSRF token, which is stored in the jar as a cookie
+	response, _, err := session.send(Request {
+		Path: "/csrf_token/",
+	})
+	if err != nil { return }
+	if session.CSRFToken() == "" {
+		return fmt.Errorf (
+			"cannot get CSRF token (%s)",
+			response.Status)
+	}
+
+	response, body, err := session.send(Request {
 		Path:	"/accounts/login/",
 		Method:	MethodPost,
 		
@@ -55,7 +85,8 @@ func (session *UserSession) Login () (err error) {
 			"X-Requested-With": "XMLHttpRequest",
 			"Content-Type": "application/json",
 		},
-	}.Send()
+	})
+	if err != nil { return }
 	
 	loginData, err := UnmarshalLoginStatusResponse(body)
 	if err != nil {
@@ -70,40 +101,373 @@ func (session *UserSession) Login () (err error) {
 			response.Status, loginData.Msg)
 	}
 
+	if session.SessionID() == "" {
+		return fmt.Errorf (
+			"cannot log in (%s): no session cookie was set",
+			response.Status)
+	}
+
 	session.id     = loginData.ID
 	session.loaded = true
-	session.valid  = false
+	session.valid  = true
 	session.token  = loginData.Token
 
-	// parse session id cookie
-	// TODO: find a more robust way of doing this...
-	session.sessionID = response.Header.Get("set-cookie")[19:386]
+	if session.onLogin != nil { session.onLogin() }
+	return
+}
+
+/* Logout ends the session on the Scratch servers, so that its session cookie
+ * can no longer be used. The session's credentials are cleared, but its
+ * username and password are kept, so Login may be called again afterwards. If
+ * the session is not loaded, this function does nothing.
+ */
+func (session *UserSession) Logout () (err error) {
+	if !session.loaded { return }
+
+	response, _, err := session.send(Request {
+		Path:   "/accounts/logout/",
+		Method: MethodPost,
+		
+		Headers: map[string] string {
+			"X-Requested-With": "XMLHttpRequest",
+		},
+	})
+	if err != nil { return }
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("cannot log out (%s)", response.Status)
+	}
+
+	session.clear()
+	if session.onLogout != nil { session.onLogout() }
 	return
 }
 
+/* OnLogin sets a function to be called whenever the session successfully logs
+ * in, including when it logs in again automatically after expiring.
+ */
+func (session *UserSession) OnLogin (callback func ()) {
+	session.onLogin = callback
+}
+
+/* OnLogout sets a function to be called when the session is ended by Logout.
+ */
+func (session *UserSession) OnLogout (callback func ()) {
+	session.onLogout = callback
+}
+
+/* OnExpire sets a function to be called when the Scratch servers stop
+ * accepting the session. If the session has a password, it will try to log in
+ * again right after this function returns.
+ */
+func (session *UserSession) OnExpire (callback func ()) {
+	session.onExpire = callback
+}
+
+/* Loaded returns whether the session has credentials that can be used to make
+ * requests.
+ */
+func (session *UserSession) Loaded () (loaded bool) {
+	return session.loaded
+}
+
+/* Valid returns whether the Scratch servers accepted the session the last time
+ * it was checked.
+ */
+func (session *UserSession) Valid () (valid bool) {
+	return session.valid
+}
+
 /* Verify checks whether a session is valid. It returns whether or not it is
  * valid, and sets the session's valid flag accordingly. If the session is not
  * loaded, this function does nothing.
  */
 func (session *UserSession) Verify () (valid bool) {
 	if !session.loaded { return }
+	_, err := session.Session()
+	return err == nil
+}
 
-	response, _, err := Request {
-		Path:      "/session/",
-		SessionID: session.sessionID,
+/* Session requests information about the session from the Scratch servers, and
+ * updates the session's user ID, username, and token with it. The session's
+ * valid flag is set according to whether the request succeeded. If the session
+ * is not loaded, this function does nothing.
+ */
+func (session *UserSession) Session () (info SessionInfo, err error) {
+	if !session.loaded { return }
+	session.valid = false
+
+	response, body, err := session.send(Request {
+		Path: "/session/",
 		
 		Headers: map[string] string {
 			"X-Requested-With": "XMLHttpRequest",
 		},
-	}.Send()
-	// TODO: parse response from server and update session object with
-	// information from it
+	})
+	if err != nil { return }
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("cannot get session (%s)", response.Status)
+		return
+	}
+
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		err = fmt.Errorf (
+			"cannot parse server response (%s): %v",
+			response.Status, err)
+		return
+	}
+
+	// scratch responds with an empty object if the session cookie is not
+	// accepted
+	if info.User.Username == "" {
+		session.expire()
+		err = fmt.Errorf("session is not logged in")
+		return
+	}
+	
+	session.info     = info
+	session.valid    = true
+	session.id       = info.User.ID
+	session.username = info.User.Username
+	session.token    = info.User.Token
+	return
+}
+
+/* Info returns the session information retrieved by the most recent successful
+ * call to Session or Verify.
+ */
+func (session *UserSession) Info () (info SessionInfo) {
+	return session.info
+}
+
+/* SessionID returns the value of the session's scratchsessionsid cookie.
+ */
+func (session *UserSession) SessionID () (sessionID string) {
+	return session.cookie("scratchsessionsid")
+}
+
+/* CSRFToken returns the value of the session's scratchcsrftoken cookie.
+ */
+func (session *UserSession) CSRFToken () (csrfToken string) {
+	return session.cookie("scratchcsrftoken")
+}
+
+/* send sends a request using the session's cookies and CSRF token. If the
+ * request is rejected because the session has expired, and the session has a
+ * password, it logs in again and retries the request once.
+ */
+func (session *UserSession) send (request Request) (
+	response *http.Response,
+	body     []byte,
+	err      error,
+) {
+	response, body, err = session.sendOnce(request)
+	if err != nil || !session.loaded || session.recovering { return }
+	status := response.StatusCode
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return
+	}
+
+	// the request may have been rejected for some other reason, so make
+	// sure that the session has actually expired first. if it has, it will
+	// no longer be loaded.
+	session.recovering = true
+	defer func () { session.recovering = false } ()
+	if session.Verify() || session.loaded { return }
 	
-	valid = err == nil && response.StatusCode == http.StatusOK
-	session.valid = valid
+	if session.password == "" { return }
+	if session.Login() != nil { return }
+	return session.sendOnce(request)
+}
+
+/* sendOnce sends a request using the session's current cookies, CSRF token,
+ * and X-Token if the request has that header.
+ */
+func (session *UserSession) sendOnce (request Request) (
+	response *http.Response,
+	body     []byte,
+	err      error,
+) {
+	if _, ok := request.Headers["X-Token"]; ok {
+		headers := make(map[string] string)
+		for key, value := range request.Headers {
+			headers[key] = value
+		}
+		headers["X-Token"] = session.token
+		request.Headers = headers
+	}
+	
+	// a nil *cookiejar.Jar would not be a nil http.CookieJar, so the jar is
+	// only set if there is one
+	if session.jar != nil { request.Jar = session.jar }
+	request.CSRFToken = session.CSRFToken()
+	return request.Send()
+}
+
+/* apiRequest sends an authenticated request to api.scratch.mit.edu, with the
+ * headers that the Scratch website sends.
+ */
+func (session *UserSession) apiRequest (
+	method Method,
+	path   string,
+	body   RequestBody,
+) (
+	response     *http.Response,
+	responseBody []byte,
+	err          error,
+) {
+	return session.send(Request {
+		Hostname: "api.scratch.mit.edu",
+		Path:     path,
+		Method:   method,
+		Body:     body,
+		
+		Headers: map[string] string {
+			"X-Token":      session.token,
+			"Content-Type": "application/json",
+			"Origin":       "https://scratch.mit.edu",
+		},
+	})
+}
+
+/* siteAPIRequest sends an authenticated request to the site-api on
+ * scratch.mit.edu, with the headers that the Scratch website sends.
+ */
+func (session *UserSession) siteAPIRequest (
+	method Method,
+	path   string,
+	body   RequestBody,
+) (
+	response     *http.Response,
+	responseBody []byte,
+	err          error,
+) {
+	return session.send(Request {
+		Path:   "/site-api" + path,
+		Method: method,
+		Body:   body,
+		
+		Headers: map[string] string {
+			"X-Requested-With": "XMLHttpRequest",
+			"Content-Type":     "application/json",
+			"Origin":           "https://scratch.mit.edu",
+		},
+	})
+}
+
+/* expire marks the session as no longer usable, and calls the expire callback
+ * if the session was previously loaded.
+ */
+func (session *UserSession) expire () {
+	wasLoaded := session.loaded
+	session.loaded = false
+	session.valid  = false
+	if wasLoaded && session.onExpire != nil { session.onExpire() }
+}
+
+/* clear removes all credentials from the session except for the username and
+ * password. The cookie jar is replaced with an empty one, so that requests made
+ * afterwards are sent without any credentials.
+ */
+func (session *UserSession) clear () {
+	session.loaded = false
+	session.valid  = false
+	session.id     = 0
+	session.token  = ""
+	session.jar, _ = cookiejar.New(nil)
+	session.info   = SessionInfo { }
+}
+
+/* cookie returns the value of a cookie stored in the session's cookie jar.
+ */
+func (session *UserSession) cookie (name string) (value string) {
+	if session.jar == nil { return }
+	for _, cookie := range session.jar.Cookies(scratchURL) {
+		if cookie.Name == name { return cookie.Value }
+	}
 	return
 }
 
+/* setCookie stores a cookie in the session's cookie jar. The cookie is sent to
+ * scratch.mit.edu and all of its subdomains.
+ */
+func (session *UserSession) setCookie (name, value string) {
+	session.jar.SetCookies(scratchURL, []*http.Cookie { {
+		Name:   name,
+		Value:  value,
+		Path:   "/",
+		Domain: scratchURL.Host,
+	} })
+}
+
+/* ImportUserSession restores a user session from data previously produced by
+ * Export, without logging in. The restored session is verified, and an error is
+ * returned if the Scratch servers no longer accept it.
+ */
+func ImportUserSession (data []byte) (session *UserSession, err error) {
+	sessionData := UserSessionData { }
+	err = json.Unmarshal(data, &sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse session data: %v", err)
+	}
+
+	session = &UserSession {
+		loaded:   true,
+		username: sessionData.Username,
+		id:       sessionData.ID,
+		token:    sessionData.Token,
+	}
+
+	session.jar, _ = cookiejar.New(nil)
+	session.setCookie("scratchlanguage",   "en")
+	session.setCookie("scratchsessionsid", sessionData.SessionID)
+	session.setCookie("scratchcsrftoken",  sessionData.CSRFToken)
+
+	if !session.Verify() {
+		return session, fmt.Errorf (
+			"session for %s is no longer valid",
+			session.username)
+	}
+	return
+}
+
+/* ImportUserSessionFile restores a user session from a file previously written
+ * by ExportFile. See ImportUserSession.
+ */
+func ImportUserSessionFile (path string) (session *UserSession, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil { return }
+	return ImportUserSession(data)
+}
+
+/* Export serializes the session's credentials into a JSON encoded byte slice
+ * that can later be passed to ImportUserSession. The password is not included.
+ * The returned data grants full access to the account, so it must be stored
+ * securely.
+ */
+func (session *UserSession) Export () (data []byte, err error) {
+	if !session.loaded {
+		return nil, fmt.Errorf("cannot export a session that is not loaded")
+	}
+
+	return json.Marshal (UserSessionData {
+		Username:  session.username,
+		ID:        session.id,
+		Token:     session.token,
+		SessionID: session.SessionID(),
+		CSRFToken: session.CSRFToken(),
+	})
+}
+
+/* ExportFile writes the session's credentials to a file that is only readable
+ * by the current user. See Export.
+ */
+func (session *UserSession) ExportFile (path string) (err error) {
+	data, err := session.Export()
+	if err != nil { return }
+	return os.WriteFile(path, data, 0600)
+}
+
 /* CommentOnProject writes a comment on a project. If parent is nonzero, this
  * comment will be in reply to the comment with that ID. If tagging is not
  * blank, this comment will "@" the user with that ID.
@@ -163,9 +527,9 @@ func (session *UserSession) comment (
 ) (
 	err error,
 ) {
-	response, body, err := Request {
+	response, body, err := session.send(Request {
 		Hostname: "api.scratch.mit.edu",
-		Path:     "/proxy/comments/" + where + "/" + id,
+		Path:     "/proxy/comments/" + where + "/" + id + "/",
 		Method:   MethodPost,
 
 		Body: CommentRequest {
@@ -183,14 +547,10 @@ func (session *UserSession) comment (
 			"Sec-Fetch-Mode": "cors",
 			"Sec-Fetch-Site": "same-site",
 		},
-
-		SessionID: session.sessionID,
-	}.Send()
+	})
 
 	if err != nil {
-		return fmt.Errorf (
-			"cannot comment (%s): %v",
-			response.Status, err)
+		return fmt.Errorf("cannot comment: %v", err)
 	}
 
 	commentData := CommentStatusResponse { }
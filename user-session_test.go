@@ -0,0 +1,77 @@
+package scapi3
+
+import "strings"
+import "testing"
+import "net/http"
+import "net/http/httptest"
+
+/* serveHosts starts a stand-in server that handles requests for every Scratch
+ * hostname, and points BaseURL at it until the test ends. The hostname is the
+ * first element of the path of each request.
+ */
+func serveHosts (test *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	previous := BaseURL
+	BaseURL = func (hostname string) string {
+		return server.URL + "/" + hostname
+	}
+	test.Cleanup(func () {
+		BaseURL = previous
+		server.Close()
+	})
+}
+
+func TestSessionWithoutJar (test *testing.T) {
+	cookies := ""
+	serveHosts(test, func (writer http.ResponseWriter, request *http.Request) {
+		cookies = request.Header.Get("Cookie")
+		if request.URL.Path != "/api.scratch.mit.edu/users/someone" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Write([]byte(`{"id":1,"username":"someone"}`))
+	})
+
+	user, err := (&UserSession { }).GetUser("someone")
+	if err != nil { test.Fatal(err) }
+	if user.Username != "someone" {
+		test.Fatalf("got user %q, expected someone", user.Username)
+	}
+	if !strings.Contains(cookies, "scratchcsrftoken=a") {
+		test.Fatalf("request without a jar sent cookies %q", cookies)
+	}
+}
+
+func TestRequestAfterLogout (test *testing.T) {
+	cookies := ""
+	serveHosts(test, func (writer http.ResponseWriter, request *http.Request) {
+		cookies = request.Header.Get("Cookie")
+		switch request.URL.Path {
+		case "/scratch.mit.edu/accounts/logout/":
+		case "/api.scratch.mit.edu/users/someone":
+			writer.Write([]byte(`{"id":1,"username":"someone"}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	session := uploadSession()
+	session.setCookie("scratchsessionsid", "secret")
+	loggedOut := false
+	session.OnLogout(func () { loggedOut = true })
+
+	err := session.Logout()
+	if err != nil { test.Fatal(err) }
+	if !loggedOut || session.Loaded() {
+		test.Fatal("session is still logged in")
+	}
+
+	user, err := session.GetUser("someone")
+	if err != nil { test.Fatal(err) }
+	if user.Username != "someone" {
+		test.Fatalf("got user %q, expected someone", user.Username)
+	}
+	if strings.Contains(cookies, "secret") {
+		test.Fatalf("request after logging out sent cookies %q", cookies)
+	}
+}
@@ -0,0 +1,335 @@
+package scapi3
+
+import "fmt"
+import "bytes"
+import "strconv"
+import "net/url"
+import "encoding/json"
+import "mime/multipart"
+
+/* AddStudioProject adds a project to a studio.
+ */
+func (session *UserSession) AddStudioProject (
+	studio  uint64,
+	project uint64,
+) (
+	err error,
+) {
+	return session.studioAPIAction (
+		"add project to", studio, MethodPost,
+		"/project/" + strconv.FormatUint(project, 10), nil)
+}
+
+/* RemoveStudioProject removes a project from a studio.
+ */
+func (session *UserSession) RemoveStudioProject (
+	studio  uint64,
+	project uint64,
+) (
+	err error,
+) {
+	return session.studioAPIAction (
+		"remove project from", studio, MethodDelete,
+		"/project/" + strconv.FormatUint(project, 10), nil)
+}
+
+/* InviteStudioCurator invites a user to curate a studio.
+ */
+func (session *UserSession) InviteStudioCurator (
+	studio uint64,
+	name   string,
+) (
+	err error,
+) {
+	return session.studioCuratorAction (
+		"invite curator to", studio, "invite_curator", name)
+}
+
+/* PromoteStudioCurator promotes a curator of a studio to a manager.
+ */
+func (session *UserSession) PromoteStudioCurator (
+	studio uint64,
+	name   string,
+) (
+	err error,
+) {
+	return session.studioCuratorAction (
+		"promote curator of", studio, "promote", name)
+}
+
+/* RemoveStudioCurator removes a curator or manager from a studio.
+ */
+func (session *UserSession) RemoveStudioCurator (
+	studio uint64,
+	name   string,
+) (
+	err error,
+) {
+	return session.studioCuratorAction (
+		"remove curator from", studio, "remove", name)
+}
+
+/* AcceptStudioInvite accepts an invitation for the session's user to curate a
+ * studio.
+ */
+func (session *UserSession) AcceptStudioInvite (studio uint64) (err error) {
+	return session.studioCuratorAction (
+		"accept invite to", studio, "add", session.username)
+}
+
+/* TransferStudioHost makes a manager of a studio its new host. Scratch requires
+ * the current host's password to do this.
+ */
+func (session *UserSession) TransferStudioHost (
+	studio   uint64,
+	name     string,
+	password string,
+) (
+	err error,
+) {
+	if password == "" {
+		return fmt.Errorf("cannot transfer studio host: no password")
+	}
+
+	return session.studioAPIAction (
+		"transfer host of", studio, MethodPut,
+		"/transfer/" + url.PathEscape(name),
+		TransferHostRequest { Password: password })
+}
+
+/* SetStudioOpenToAll sets whether anyone can add projects to a studio.
+ */
+func (session *UserSession) SetStudioOpenToAll (
+	studio uint64,
+	open   bool,
+) (
+	err error,
+) {
+	mark := "closed"
+	if open {
+		mark = "open"
+	}
+
+	return session.studioSiteAction (
+		"change permissions of", studio, MethodPut,
+		"/galleries/" + strconv.FormatUint(studio, 10) +
+		"/mark/" + mark + "/")
+}
+
+/* SetStudioCommentsAllowed sets whether comments can be posted on a studio.
+ * Scratch only provides a way to toggle this, so the studio is checked first.
+ */
+func (session *UserSession) SetStudioCommentsAllowed (
+	studio  uint64,
+	allowed bool,
+) (
+	err error,
+) {
+	current, err := session.GetStudio(studio)
+	if err != nil { return }
+	if current.CommentsAllowed == allowed { return }
+	return session.ToggleStudioComments(studio)
+}
+
+/* ToggleStudioComments toggles whether comments can be posted on a studio.
+ */
+func (session *UserSession) ToggleStudioComments (studio uint64) (err error) {
+	return session.studioSiteAction (
+		"toggle comments on", studio, MethodPost,
+		"/comments/gallery/" + strconv.FormatUint(studio, 10) +
+		"/toggle-comments/")
+}
+
+/* SetStudioTitle changes the title of a studio, and returns the updated studio.
+ */
+func (session *UserSession) SetStudioTitle (
+	studio uint64,
+	title  string,
+) (
+	structure StudioResponse,
+	err error,
+) {
+	return session.UpdateStudio(studio, StudioUpdateRequest {
+		Title: &title,
+	})
+}
+
+/* SetStudioDescription changes the description of a studio, and returns the
+ * updated studio.
+ */
+func (session *UserSession) SetStudioDescription (
+	studio      uint64,
+	description string,
+) (
+	structure StudioResponse,
+	err error,
+) {
+	return session.UpdateStudio(studio, StudioUpdateRequest {
+		Description: &description,
+	})
+}
+
+/* UpdateStudio changes the title and/or description of a studio, and returns
+ * the updated studio.
+ */
+func (session *UserSession) UpdateStudio (
+	studio uint64,
+	update StudioUpdateRequest,
+) (
+	structure StudioResponse,
+	err error,
+) {
+	target := "studio " + strconv.FormatUint(studio, 10)
+	response, body, err := session.apiRequest (
+		MethodPut, "/studios/" + strconv.FormatUint(studio, 10),
+		update)
+	if err != nil {
+		err = fmt.Errorf("cannot update %s: %v", target, err)
+		return
+	}
+	err = checkAction("update", target, response)
+	if err != nil { return }
+
+	err = json.Unmarshal(body, &structure)
+	if err != nil {
+		err = fmt.Errorf (
+			"cannot parse server response (%s): %v",
+			response.Status, err)
+	}
+	return
+}
+
+/* SetStudioThumbnail uploads a new thumbnail image for a studio. The image
+ * must be in a format that the Scratch website accepts, such as PNG, JPEG, or
+ * GIF.
+ */
+func (session *UserSession) SetStudioThumbnail (
+	studio uint64,
+	image  []byte,
+) (
+	err error,
+) {
+	target := "studio " + strconv.FormatUint(studio, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot set thumbnail of %s: session is not loaded",
+			target)
+	}
+
+	body, contentType, err := multipartFile("file", "thumbnail", image)
+	if err != nil { return }
+
+	response, _, err := session.send(Request {
+		Path:   "/site-api/galleries/all/" +
+			strconv.FormatUint(studio, 10) + "/",
+		Method: MethodPost,
+		Body:   body,
+
+		Headers: map[string] string {
+			"X-Requested-With": "XMLHttpRequest",
+			"Content-Type":     contentType,
+			"Origin":           "https://scratch.mit.edu",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set thumbnail of %s: %v", target, err)
+	}
+	return checkAction("set thumbnail of", target, response)
+}
+
+/* studioAPIAction sends an authenticated request concerning a studio to
+ * api.scratch.mit.edu.
+ */
+func (session *UserSession) studioAPIAction (
+	action string,
+	studio uint64,
+	method Method,
+	path   string,
+	body   RequestBody,
+) (
+	err error,
+) {
+	target := "studio " + strconv.FormatUint(studio, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot %s %s: session is not loaded",
+			action, target)
+	}
+
+	response, _, err := session.apiRequest (
+		method,
+		"/studios/" + strconv.FormatUint(studio, 10) + path,
+		body)
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %v", action, target, err)
+	}
+	return checkAction(action, target, response)
+}
+
+/* studioSiteAction sends an authenticated request concerning a studio to the
+ * site-api.
+ */
+func (session *UserSession) studioSiteAction (
+	action string,
+	studio uint64,
+	method Method,
+	path   string,
+) (
+	err error,
+) {
+	target := "studio " + strconv.FormatUint(studio, 10)
+	if !session.loaded {
+		return fmt.Errorf (
+			"cannot %s %s: session is not loaded",
+			action, target)
+	}
+
+	response, _, err := session.siteAPIRequest(method, path, nil)
+	if err != nil {
+		return fmt.Errorf("cannot %s %s: %v", action, target, err)
+	}
+	return checkAction(action, target, response)
+}
+
+/* studioCuratorAction sends a site-api request that changes the curator status
+ * of a user in a studio.
+ */
+func (session *UserSession) studioCuratorAction (
+	action string,
+	studio uint64,
+	what   string,
+	name   string,
+) (
+	err error,
+) {
+	return session.studioSiteAction (
+		action, studio, MethodPut,
+		"/users/curators-in/" + strconv.FormatUint(studio, 10) +
+		"/" + what + "/?usernames=" + url.QueryEscape(name))
+}
+
+/* multipartFile encodes a single file as a multipart form, as used by the
+ * site-api for image uploads. It returns the encoded body and the value of the
+ * Content-Type header that must be sent along with it.
+ */
+func multipartFile (
+	field    string,
+	filename string,
+	data     []byte,
+) (
+	body        RawRequestBody,
+	contentType string,
+	err         error,
+) {
+	buffer := &bytes.Buffer { }
+	writer := multipart.NewWriter(buffer)
+	
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil { return }
+	_, err = part.Write(data)
+	if err != nil { return }
+	err = writer.Close()
+	if err != nil { return }
+
+	return RawRequestBody(buffer.Bytes()), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,205 @@
+package scapi3
+
+import "io"
+import "fmt"
+import "mime"
+import "bytes"
+import "strconv"
+import "net/url"
+import "net/http"
+import "path/filepath"
+import "encoding/json"
+import "github.com/scapi3/sb3"
+
+/* CreateProject uploads an sb3 file as a new project owned by the session's
+ * user, and returns the ID of the new project. Any assets used by the project
+ * that are not already on the Scratch asset server are uploaded as well.
+ */
+func (session *UserSession) CreateProject (
+	file  io.Reader,
+	title string,
+) (
+	id  uint64,
+	err error,
+) {
+	projectJSON, err := session.uploadProjectAssets(file)
+	if err != nil { return }
+	
+	query := url.Values { }
+	query.Set("is_remix",    "0")
+	query.Set("original_id", "0")
+	query.Set("title",       title)
+	
+	response, body, err := session.projectsRequest (
+		MethodPost, "/?" + query.Encode(),
+		projectJSON)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create project: %v", err)
+	}
+	err = checkAction("create", "project", response)
+	if err != nil { return }
+
+	uploadData := ProjectUploadResponse { }
+	err = json.Unmarshal(body, &uploadData)
+	if err != nil {
+		return 0, fmt.Errorf (
+			"cannot parse server response (%s): %v",
+			response.Status, err)
+	}
+	
+	id, err = strconv.ParseUint(uploadData.ContentName.String(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf (
+			"cannot parse new project ID %q: %v",
+			uploadData.ContentName, err)
+	}
+	return
+}
+
+/* UpdateProject replaces the contents of an existing project with an sb3 file.
+ * Any assets used by the project that are not already on the Scratch asset
+ * server are uploaded as well.
+ */
+func (session *UserSession) UpdateProject (
+	id   uint64,
+	file io.Reader,
+) (
+	err error,
+) {
+	target := "project " + strconv.FormatUint(id, 10)
+	projectJSON, err := session.uploadProjectAssets(file)
+	if err != nil { return }
+	
+	response, _, err := session.projectsRequest (
+		MethodPut, "/" + strconv.FormatUint(id, 10),
+		projectJSON)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %v", target, err)
+	}
+	return checkAction("update", target, response)
+}
+
+/* UploadAsset uploads an asset to the Scratch asset server under the specified
+ * name, which must be the MD5 hash of the data followed by its file extension.
+ * If the asset server already has it, it is not uploaded again.
+ */
+func (session *UserSession) UploadAsset (md5ext string, data []byte) (err error) {
+	target := "asset " + md5ext
+	err = sb3.VerifyAsset(md5ext, data)
+	if err != nil {
+		return fmt.Errorf("cannot upload %s: %v", target, err)
+	}
+	has, err := session.hasAsset(md5ext)
+	if err != nil {
+		return fmt.Errorf("cannot upload %s: %v", target, err)
+	}
+	if has { return }
+
+	contentType := mime.TypeByExtension(filepath.Ext(md5ext))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	response, _, err := session.send(Request {
+		Hostname: "assets.scratch.mit.edu",
+		Path:     "/" + url.PathEscape(md5ext),
+		Method:   MethodPost,
+		Body:     RawRequestBody(data),
+		
+		Headers: map[string] string {
+			"X-Token":      session.token,
+			"Content-Type": contentType,
+			"Origin":       "https://scratch.mit.edu",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot upload %s: %v", target, err)
+	}
+	return checkAction("upload", target, response)
+}
+
+/* hasAsset returns whether the Scratch asset server already has an asset. It
+ * checks the same path that GetAsset downloads from. An error is returned if
+ * the server cannot be reached, or gives neither a yes nor a no.
+ */
+func (session *UserSession) hasAsset (md5ext string) (has bool, err error) {
+	response, _, err := session.send(Request {
+		Hostname: "assets.scratch.mit.edu",
+		Path:     assetPath(md5ext),
+		Method:   MethodHead,
+	})
+	if err != nil { return }
+	
+	switch response.StatusCode {
+	case http.StatusOK:       return true, nil
+	case http.StatusNotFound: return false, nil
+	default:
+		return false, fmt.Errorf (
+			"cannot check asset %s (%s)",
+			md5ext, response.Status)
+	}
+}
+
+/* uploadProjectAssets reads an sb3 file, uploads the assets that its project
+ * uses, and returns its project.json file. Assets that the file does not
+ * contain must already be on the asset server.
+ */
+func (session *UserSession) uploadProjectAssets (
+	file io.Reader,
+) (
+	projectJSON RawRequestBody,
+	err error,
+) {
+	if !session.loaded {
+		return nil, fmt.Errorf("cannot upload project: session is not loaded")
+	}
+	
+	data, err := io.ReadAll(file)
+	if err != nil { return }
+	archive, err := sb3.Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil { return }
+
+	for _, name := range sb3.ReferencedAssets(archive.Project) {
+		if !archive.HasAsset(name) {
+			var has bool
+			has, err = session.hasAsset(name)
+			if err != nil { return }
+			if has { continue }
+			return nil, fmt.Errorf (
+				"cannot upload project: %s is missing", name)
+		}
+
+		var contents []byte
+		contents, err = archive.Asset(name)
+		if err != nil { return }
+		err = session.UploadAsset(name, contents)
+		if err != nil { return }
+	}
+
+	return archive.Project.Marshal()
+}
+
+/* projectsRequest sends an authenticated request to projects.scratch.mit.edu.
+ */
+func (session *UserSession) projectsRequest (
+	method Method,
+	path   string,
+	body   RequestBody,
+) (
+	response     *http.Response,
+	responseBody []byte,
+	err          error,
+) {
+	return session.send(Request {
+		Hostname: "projects.scratch.mit.edu",
+		Path:     path,
+		Method:   method,
+		Body:     body,
+		
+		Headers: map[string] string {
+			"X-Token":      session.token,
+			"Content-Type": "application/json",
+			"Origin":       "https://scratch.mit.edu",
+		},
+	})
+}
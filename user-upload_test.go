@@ -0,0 +1,254 @@
+package scapi3
+
+import "io"
+import "sync"
+import "bytes"
+import "strings"
+import "testing"
+import "net/http"
+import "archive/zip"
+import "encoding/json"
+import "net/http/cookiejar"
+import "net/http/httptest"
+import "github.com/scapi3/sb3"
+import "github.com/scapi3/builder"
+
+/* uploadServer is a stand-in for the projects and assets servers. Requests for
+ * each Scratch hostname are sent to a path starting with that hostname.
+ */
+type uploadServer struct {
+	lock     sync.Mutex
+	assets   map[string] []byte
+	uploads  []string
+	projects map[string] []byte
+	titles   []string
+
+	// checkStatus, if set, is the status that HEAD requests get instead
+	// of an answer.
+	checkStatus int
+}
+
+/* newUploadServer starts a stand-in server, and points BaseURL at it until the
+ * test ends.
+ */
+func newUploadServer (test *testing.T) (server *uploadServer) {
+	server = &uploadServer {
+		assets:   map[string] []byte { },
+		projects: map[string] []byte { },
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(server.handle))
+
+	previous := BaseURL
+	BaseURL = func (hostname string) string {
+		return httpServer.URL + "/" + hostname
+	}
+	test.Cleanup(func () {
+		BaseURL = previous
+		httpServer.Close()
+	})
+	return
+}
+
+/* handle responds to a request like the projects or assets server would. All
+ * requests but HEAD requests must carry the session's token.
+ */
+func (server *uploadServer) handle (
+	writer  http.ResponseWriter,
+	request *http.Request,
+) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	host, path, _ := strings.Cut(strings.TrimPrefix(request.URL.Path, "/"), "/")
+	if request.Method != http.MethodHead && request.Header.Get("X-Token") != "token" {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(request.Body)
+
+	switch {
+	case host == "assets.scratch.mit.edu" && request.Method == http.MethodHead:
+		if server.checkStatus != 0 {
+			writer.WriteHeader(server.checkStatus)
+			return
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "internalapi/asset/"), "/get/")
+		if _, ok := server.assets[name]; !ok || "/" + path != assetPath(name) {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	case host == "assets.scratch.mit.edu" && request.Method == http.MethodPost:
+		server.assets[path] = body
+		server.uploads = append(server.uploads, path)
+		writer.Write([]byte(`{"status":"ok"}`))
+
+	case host == "projects.scratch.mit.edu" && request.Method == http.MethodPost:
+		server.projects["1234"] = body
+		server.titles = append(server.titles, request.URL.Query().Get("title"))
+		writer.Write([]byte (
+			`{"status":"ok","content-name":"1234","content-title":"x"}`))
+	case host == "projects.scratch.mit.edu" && request.Method == http.MethodPut:
+		if _, ok := server.projects[path]; !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		server.projects[path] = body
+		writer.Write([]byte(`{"status":"ok"}`))
+
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+/* uploadSession returns a session that appears to be logged in, without
+ * contacting any server.
+ */
+func uploadSession () (session *UserSession) {
+	jar, _ := cookiejar.New(nil)
+	return &UserSession {
+		loaded:   true,
+		username: "tester",
+		token:    "token",
+		jar:      jar,
+	}
+}
+
+/* testProject builds an sb3 file with a sprite that has a costume and a sound,
+ * and returns it along with the names of its assets.
+ */
+func testProject (test *testing.T) (file []byte, names []string) {
+	project := builder.New()
+	project.Sprite("Cat").
+		Costume("cat", "svg", []byte(`<svg width="10" height="10"/>`)).
+		Sound("meow", "wav", []byte("RIFF meow"), 22050, 4)
+	project.Stage().Costume("backdrop", "svg", []byte(`<svg width="480" height="360"/>`))
+
+	built, assets, err := project.Build()
+	if err != nil { test.Fatal(err) }
+	buffer := bytes.Buffer { }
+	err = sb3.Write(&buffer, built, assets)
+	if err != nil { test.Fatal(err) }
+	return buffer.Bytes(), sb3.ReferencedAssets(built)
+}
+
+func TestCreateProject (test *testing.T) {
+	server := newUploadServer(test)
+	file, names := testProject(test)
+
+	id, err := uploadSession().CreateProject(bytes.NewReader(file), "Starter")
+	if err != nil { test.Fatal(err) }
+	if id != 1234 {
+		test.Fatalf("created project %d, expected 1234", id)
+	}
+	if len(server.titles) != 1 || server.titles[0] != "Starter" {
+		test.Fatalf("project titles are %v", server.titles)
+	}
+	if len(server.uploads) != len(names) {
+		test.Fatalf("uploaded %v, expected %v", server.uploads, names)
+	}
+	for _, name := range names {
+		if sb3.VerifyAsset(name, server.assets[name]) != nil {
+			test.Fatalf("asset %s was not uploaded correctly", name)
+		}
+	}
+
+	uploaded, err := sb3.Parse(server.projects["1234"])
+	if err != nil { test.Fatal(err) }
+	if uploaded.Target("Cat") == nil {
+		test.Fatal("uploaded project has no sprite named Cat")
+	}
+}
+
+func TestUpdateProject (test *testing.T) {
+	server := newUploadServer(test)
+	file, _ := testProject(test)
+	session := uploadSession()
+
+	err := session.UpdateProject(1234, bytes.NewReader(file))
+	if err == nil {
+		test.Fatal("updating a project that does not exist succeeded")
+	}
+
+	server.projects["1234"] = []byte("{}")
+	err = session.UpdateProject(1234, bytes.NewReader(file))
+	if err != nil { test.Fatal(err) }
+
+	var uploaded map[string] any
+	err = json.Unmarshal(server.projects["1234"], &uploaded)
+	if err != nil { test.Fatal(err) }
+	if _, ok := uploaded["targets"]; !ok {
+		test.Fatalf("uploaded project has no targets: %s", server.projects["1234"])
+	}
+}
+
+func TestUploadSkipsExistingAssets (test *testing.T) {
+	server := newUploadServer(test)
+	file, names := testProject(test)
+	server.assets[names[0]] = []byte("already there")
+
+	_, err := uploadSession().CreateProject(bytes.NewReader(file), "Starter")
+	if err != nil { test.Fatal(err) }
+	for _, name := range server.uploads {
+		if name == names[0] {
+			test.Fatalf("existing asset %s was uploaded again", name)
+		}
+	}
+	if len(server.uploads) != len(names) - 1 {
+		test.Fatalf("uploaded %v, expected all of %v but the first", server.uploads, names)
+	}
+}
+
+func TestUploadChecksAssets (test *testing.T) {
+	server := newUploadServer(test)
+	file, names := testProject(test)
+
+	// rewrite the archive with one asset missing, and another replaced
+	// by data that does not match its name
+	original, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	if err != nil { test.Fatal(err) }
+	rewrite := func (skip, corrupt string) []byte {
+		buffer := bytes.Buffer { }
+		writer := zip.NewWriter(&buffer)
+		for _, entry := range original.File {
+			if entry.Name == skip { continue }
+			reader, _ := entry.Open()
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			if entry.Name == corrupt { data = []byte("corrupt") }
+			output, _ := writer.Create(entry.Name)
+			output.Write(data)
+		}
+		writer.Close()
+		return buffer.Bytes()
+	}
+
+	session := uploadSession()
+	_, err = session.CreateProject(bytes.NewReader(rewrite("", names[0])), "Bad")
+	if err == nil { test.Fatal("asset with the wrong hash was uploaded") }
+	if _, ok := server.assets[names[0]]; ok {
+		test.Fatalf("asset with the wrong hash was stored")
+	}
+
+	_, err = session.CreateProject(bytes.NewReader(rewrite(names[1], "")), "Missing")
+	if err == nil { test.Fatal("project with a missing asset was uploaded") }
+
+	// assets that the server already has do not need to be in the file
+	server.assets[names[1]] = []byte("already there")
+	_, err = session.CreateProject(bytes.NewReader(rewrite(names[1], "")), "Partial")
+	if err != nil { test.Fatal(err) }
+}
+
+func TestUploadFailsWhenCheckFails (test *testing.T) {
+	server := newUploadServer(test)
+	file, _ := testProject(test)
+	server.checkStatus = http.StatusServiceUnavailable
+
+	// an asset server that cannot say whether it has an asset must not be
+	// taken to be missing it
+	_, err := uploadSession().CreateProject(bytes.NewReader(file), "Starter")
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		test.Fatalf("error is %v, expected the failed check", err)
+	}
+	if len(server.uploads) != 0 {
+		test.Fatalf("uploaded %v after the check failed", server.uploads)
+	}
+}